@@ -0,0 +1,63 @@
+package checksum
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var ErrNotFound = errors.New("no checksum entry found")
+
+// Sums maps artifact file names to their hex encoded SHA-256 digest.
+// A checksum file holding a single digest with no file name (e.g. `foo.tar.gz.sha256`) is stored under the empty key.
+type Sums map[string]string
+
+// Parse reads checksums in the `sha256sum` format (`<digest>  <file>` or `<digest> *<file>`) as produced by goreleaser.
+// Lines with only a digest are accepted to support per-artifact `.sha256` files.
+func Parse(r io.Reader) (Sums, error) {
+	out := Sums{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		digest := strings.ToLower(fields[0])
+		if _, err := hex.DecodeString(digest); err != nil || len(digest) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid sha256 digest in line %q", line)
+		}
+		name := ""
+		if len(fields) > 1 {
+			name = path.Base(strings.TrimPrefix(strings.Join(fields[1:], " "), "*"))
+		}
+		out[name] = digest
+	}
+	return out, scanner.Err()
+}
+
+// Lookup returns the digest for the artifact with this file name.
+// If the file only had a single anonymous digest it is returned for any name.
+func (s Sums) Lookup(name string) (string, error) {
+	if d, ok := s[path.Base(name)]; ok {
+		return d, nil
+	}
+	if d, ok := s[""]; ok && len(s) == 1 {
+		return d, nil
+	}
+	return "", fmt.Errorf("%w for %s", ErrNotFound, name)
+}
+
+// Digest streams r and returns its hex encoded SHA-256 digest.
+func Digest(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,79 @@
+package checksum_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kumahq/ci-tools/cmd/internal/checksum"
+)
+
+const (
+	digestA = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	digestB = "486ea46224d1bb4fb680f34f7c9ad96a8f24ec88be73ea8e5a6c65260e9cb8a7"
+)
+
+func TestParseAndLookup(t *testing.T) {
+	for _, v := range []struct {
+		desc     string
+		in       string
+		name     string
+		expected string
+		err      error
+	}{
+		{
+			desc:     "goreleaser checksums.txt",
+			in:       digestA + "  kuma-2.11.8-darwin-amd64.tar.gz\n" + digestB + "  kuma-2.11.8-linux-amd64.tar.gz\n",
+			name:     "kuma-2.11.8-linux-amd64.tar.gz",
+			expected: digestB,
+		},
+		{
+			desc:     "binary mode marker and path",
+			in:       digestA + " *dist/kuma-2.11.8-darwin-amd64.tar.gz\n",
+			name:     "kuma-2.11.8-darwin-amd64.tar.gz",
+			expected: digestA,
+		},
+		{
+			desc:     "single digest file",
+			in:       strings.ToUpper(digestA) + "\n",
+			name:     "anything.tar.gz",
+			expected: digestA,
+		},
+		{
+			desc: "missing entry",
+			in:   digestA + "  kuma-2.11.8-darwin-amd64.tar.gz\n",
+			name: "kuma-2.11.8-linux-arm64.tar.gz",
+			err:  checksum.ErrNotFound,
+		},
+	} {
+		t.Run(v.desc, func(t *testing.T) {
+			sums, err := checksum.Parse(strings.NewReader(v.in))
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			res, err := sums.Lookup(v.name)
+			if !errors.Is(err, v.err) {
+				t.Fatalf("expected error %v got %v", v.err, err)
+			}
+			if res != v.expected {
+				t.Errorf("got %s expected %s", res, v.expected)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := checksum.Parse(strings.NewReader("notadigest  foo.tar.gz\n")); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestDigest(t *testing.T) {
+	res, err := checksum.Digest(strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if res != digestA {
+		t.Errorf("got %s expected %s", res, digestA)
+	}
+}
@@ -0,0 +1,346 @@
+// Package gitea implements github.Forge on top of the Gitea REST API (v1).
+package gitea
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kumahq/ci-tools/cmd/internal/github"
+)
+
+// maxMergeBaseCommits is how far back in the history of head MergeBase looks for a common ancestor.
+const maxMergeBaseCommits = 5000
+
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+var _ github.Forge = &Client{}
+
+// New creates a client for the Gitea instance at baseURL, token is optional for public repositories.
+func New(baseURL string, token string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/") + "/api/v1", token: token, httpClient: httpClient}
+}
+
+type release struct {
+	Id          int       `json:"id"`
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Body        string    `json:"body"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	CreatedAt   time.Time `json:"created_at"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+func (r release) toRelease() github.Release {
+	out := github.Release{
+		Name:         r.Name,
+		CreatedAt:    r.CreatedAt,
+		IsDraft:      r.Draft,
+		IsPrerelease: r.Prerelease,
+		Description:  r.Body,
+		Id:           r.Id,
+	}
+	if !r.Draft {
+		out.PublishedAt = r.PublishedAt
+	}
+	return out
+}
+
+// Releases lists the releases of the repository, the latest is the most recent one that's neither a draft nor a prerelease.
+func (c *Client) Releases(repo string) ([]github.Release, error) {
+	start := time.Now()
+	var out []github.Release
+	latest := -1
+	const limit = 50
+	for page := 1; ; page++ {
+		var releases []release
+		if err := c.do(context.Background(), http.MethodGet, repoPath(repo, "releases")+fmt.Sprintf("?limit=%d&page=%d", limit, page), nil, &releases); err != nil {
+			return nil, err
+		}
+		for _, r := range releases {
+			out = append(out, r.toRelease())
+			if latest == -1 && out[len(out)-1].IsReleased() {
+				latest = len(out) - 1
+			}
+		}
+		if len(releases) < limit {
+			break
+		}
+	}
+	if latest != -1 {
+		out[latest].IsLatest = true
+	}
+	slog.Info("fetched releases", "repo", repo, "releases", len(out), "duration", time.Since(start))
+	return out, nil
+}
+
+func (c *Client) FindRelease(repo, releaseName, tagName string) (*github.Release, error) {
+	releases, err := c.Releases(repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range releases {
+		if r.Name == releaseName || r.Name == tagName {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+type commit struct {
+	Sha    string `json:"sha"`
+	Commit struct {
+		Message   string `json:"message"`
+		Committer struct {
+			Date time.Time `json:"date"`
+		} `json:"committer"`
+	} `json:"commit"`
+}
+
+type pullRequest struct {
+	Number         int       `json:"number"`
+	Title          string    `json:"title"`
+	Body           string    `json:"body"`
+	Merged         bool      `json:"merged"`
+	MergeCommitSha string    `json:"merge_commit_sha"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Base           struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// clockSkew is the margin taken on commit dates when looking for the pull requests that created them.
+const clockSkew = time.Hour
+
+// commits pages through the history of ref calling fn for each commit until it returns false.
+func (c *Client) commits(ctx context.Context, repo, ref string, fn func(commit) (bool, error)) error {
+	const limit = 50
+	for page := 1; ; page++ {
+		var commits []commit
+		q := url.Values{"sha": {ref}, "limit": {strconv.Itoa(limit)}, "page": {strconv.Itoa(page)}, "stat": {"false"}, "verification": {"false"}, "files": {"false"}}
+		if err := c.do(ctx, http.MethodGet, repoPath(repo, "commits")+"?"+q.Encode(), nil, &commits); err != nil {
+			return err
+		}
+		for _, cm := range commits {
+			next, err := fn(cm)
+			if err != nil || !next {
+				return err
+			}
+		}
+		if len(commits) < limit {
+			return nil
+		}
+	}
+}
+
+// History lists the commits of branch, the pull request of a commit is the merged one whose merge commit it is.
+// Pull requests are listed in pages of the most recently updated closed ones until the oldest commit, not looked up for each commit.
+func (c *Client) History(repo, branch, until string) ([]github.Commit, error) {
+	start := time.Now()
+	var out []github.Commit
+	defer func() {
+		slog.Info("fetched history", "repo", repo, "branch", branch, "commits", len(out), "duration", time.Since(start))
+	}()
+	var commits []commit
+	err := c.commits(context.Background(), repo, branch, func(cm commit) (bool, error) {
+		if until != "" && strings.HasPrefix(cm.Sha, until) {
+			return false, nil
+		}
+		commits = append(commits, cm)
+		return true, nil
+	})
+	if err != nil || len(commits) == 0 {
+		return nil, err
+	}
+	prs, err := c.pullRequests(context.Background(), repo, branch, commits)
+	if err != nil {
+		return nil, err
+	}
+	for _, cm := range commits {
+		out = append(out, github.Commit{Sha: cm.Sha, Message: cm.Commit.Message, PullRequest: prs[cm.Sha]})
+	}
+	return out, nil
+}
+
+// pullRequests returns the pull requests merged into branch by their merge commit.
+func (c *Client) pullRequests(ctx context.Context, repo, branch string, commits []commit) (map[string]*github.PullRequest, error) {
+	wanted := map[string]bool{}
+	oldest := commits[0].Commit.Committer.Date
+	for _, cm := range commits {
+		wanted[cm.Sha] = true
+		if cm.Commit.Committer.Date.Before(oldest) {
+			oldest = cm.Commit.Committer.Date
+		}
+	}
+	cutoff := oldest.Add(-clockSkew)
+	out := map[string]*github.PullRequest{}
+	const limit = 50
+	for page := 1; len(out) < len(wanted); page++ {
+		var prs []pullRequest
+		q := url.Values{"state": {"closed"}, "sort": {"recentupdate"}, "limit": {strconv.Itoa(limit)}, "page": {strconv.Itoa(page)}}
+		if err := c.do(ctx, http.MethodGet, repoPath(repo, "pulls")+"?"+q.Encode(), nil, &prs); err != nil {
+			return nil, err
+		}
+		for _, pr := range prs {
+			// Sorted by update so all the next ones were updated before the oldest commit was created
+			if pr.UpdatedAt.Before(cutoff) {
+				return out, nil
+			}
+			if pr.Merged && pr.Base.Ref == branch && wanted[pr.MergeCommitSha] {
+				out[pr.MergeCommitSha] = &github.PullRequest{Number: pr.Number, Title: pr.Title, Body: pr.Body, Author: pr.User.Login}
+			}
+		}
+		if len(prs) < limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (c *Client) CommitByRef(repo, tag string) (string, error) {
+	var out struct {
+		Commit struct {
+			Sha string `json:"sha"`
+		} `json:"commit"`
+	}
+	err := c.do(context.Background(), http.MethodGet, repoPath(repo, "tags/"+url.PathEscape(tag)), nil, &out)
+	if errors.Is(err, github.ErrNotFound) {
+		return "", nil
+	}
+	return out.Commit.Sha, err
+}
+
+// MergeBase walks the history of both refs as Gitea has no merge-base API, it only looks at the last maxMergeBaseCommits commits of head.
+func (c *Client) MergeBase(ctx context.Context, repo, base, head string) (string, error) {
+	inHead := map[string]bool{}
+	err := c.commits(ctx, repo, head, func(cm commit) (bool, error) {
+		inHead[cm.Sha] = true
+		return len(inHead) < maxMergeBaseCommits, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	var out string
+	err = c.commits(ctx, repo, base, func(cm commit) (bool, error) {
+		if inHead[cm.Sha] {
+			out = cm.Sha
+			return false, nil
+		}
+		return true, ctx.Err()
+	})
+	if err == nil && out == "" {
+		err = fmt.Errorf("no merge-base between %s and %s in the last %d commits of %s", base, head, maxMergeBaseCommits, head)
+	}
+	return out, err
+}
+
+func (c *Client) Compare(ctx context.Context, repo, base, head string) (github.Comparison, error) {
+	mergeBase, err := c.MergeBase(ctx, repo, base, head)
+	if err != nil {
+		return github.Comparison{}, err
+	}
+	var out struct {
+		TotalCommits int `json:"total_commits"`
+	}
+	if err := c.do(ctx, http.MethodGet, repoPath(repo, "compare/"+mergeBase+"..."+head), nil, &out); err != nil {
+		return github.Comparison{}, err
+	}
+	return github.Comparison{MergeBase: mergeBase, AheadBy: out.TotalCommits}, nil
+}
+
+func (c *Client) UpsertRelease(ctx context.Context, repo, releaseName, tagName string, contentModifier func(*github.ReleaseContent) error) error {
+	existing, err := c.FindRelease(repo, releaseName, tagName)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		content := &github.ReleaseContent{Name: releaseName, Tag: tagName, Draft: true}
+		if err := contentModifier(content); err != nil {
+			return err
+		}
+		return c.do(ctx, http.MethodPost, repoPath(repo, "releases"), map[string]any{
+			"tag_name": content.Tag,
+			"name":     content.Name,
+			"body":     content.Body,
+			"draft":    content.Draft,
+		}, nil)
+	}
+	var r release
+	if err := c.do(ctx, http.MethodGet, repoPath(repo, fmt.Sprintf("releases/%d", existing.Id)), nil, &r); err != nil {
+		return err
+	}
+	content := &github.ReleaseContent{Name: r.Name, Tag: r.TagName, Body: r.Body, Draft: r.Draft}
+	if err := contentModifier(content); err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPatch, repoPath(repo, fmt.Sprintf("releases/%d", existing.Id)), map[string]any{
+		"tag_name": content.Tag,
+		"name":     content.Name,
+		"body":     content.Body,
+		"draft":    content.Draft,
+	}, nil)
+}
+
+func repoPath(repo string, resource string) string {
+	owner, name := github.SplitRepo(repo)
+	return fmt.Sprintf("/repos/%s/%s/%s", url.PathEscape(owner), url.PathEscape(name), resource)
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, body any, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	start := time.Now()
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	slog.Debug("gitea request", "method", method, "path", path, "status", res.StatusCode, "duration", time.Since(start))
+	if err := github.CheckResponse(res); err != nil {
+		return err
+	}
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode %s %s: %w", method, path, err)
+		}
+	}
+	return nil
+}
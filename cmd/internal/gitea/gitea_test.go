@@ -0,0 +1,161 @@
+package gitea_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/kumahq/ci-tools/cmd/internal/gitea"
+	"github.com/kumahq/ci-tools/cmd/internal/github"
+)
+
+func newServer(t *testing.T, handlers map[string]http.HandlerFunc) *gitea.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		h, ok := handlers[r.Method+" "+r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		h(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return gitea.New(srv.URL, "token", srv.Client())
+}
+
+func reply(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}
+}
+
+func TestReleases(t *testing.T) {
+	client := newServer(t, map[string]http.HandlerFunc{
+		"GET /api/v1/repos/kumahq/kuma/releases": reply(`[
+			{"id":3,"name":"2.12.0","tag_name":"2.12.0","draft":true,"created_at":"2025-09-01T00:00:00Z"},
+			{"id":2,"name":"2.12.0-rc.1","tag_name":"2.12.0-rc.1","prerelease":true,"created_at":"2025-08-01T00:00:00Z","published_at":"2025-08-01T00:00:00Z"},
+			{"id":1,"name":"2.11.0","tag_name":"2.11.0","body":"## Changelog","created_at":"2025-06-20T00:00:00Z","published_at":"2025-06-21T00:00:00Z"}
+		]`),
+	})
+	releases, err := client.Releases("kumahq/kuma")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var latest []string
+	for _, r := range releases {
+		if r.IsLatest {
+			latest = append(latest, r.Name)
+		}
+	}
+	if len(releases) != 3 || !reflect.DeepEqual(latest, []string{"2.11.0"}) {
+		t.Errorf("expected 3 releases with 2.11.0 latest got %+v", releases)
+	}
+	if !releases[0].IsDraft || !releases[1].IsPrerelease || releases[2].Id != 1 || releases[2].Description != "## Changelog" {
+		t.Errorf("unexpected releases %+v", releases)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	client := newServer(t, map[string]http.HandlerFunc{
+		"GET /api/v1/repos/kumahq/kuma/commits": func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("sha") != "release-2.11" {
+				t.Errorf("unexpected ref %s", r.URL.Query().Get("sha"))
+			}
+			_, _ = io.WriteString(w, `[
+				{"sha":"c3","commit":{"message":"feat: x (#3)","committer":{"date":"2025-06-03T00:00:00Z"}}},
+				{"sha":"c2","commit":{"message":"direct push","committer":{"date":"2025-06-02T00:00:00Z"}}},
+				{"sha":"c1","commit":{"message":"older","committer":{"date":"2025-06-01T00:00:00Z"}}}
+			]`)
+		},
+		"GET /api/v1/repos/kumahq/kuma/pulls": func(w http.ResponseWriter, r *http.Request) {
+			if q := r.URL.Query(); q.Get("state") != "closed" || q.Get("sort") != "recentupdate" || q.Get("page") != "1" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			_, _ = io.WriteString(w, `[
+				{"number":4,"title":"feat: x on master","merged":true,"merge_commit_sha":"m4","updated_at":"2025-06-04T00:00:00Z","base":{"ref":"master"}},
+				{"number":3,"title":"feat: x","body":"body","merged":true,"merge_commit_sha":"c3","updated_at":"2025-06-03T00:00:00Z","base":{"ref":"release-2.11"},"user":{"login":"alice"}},
+				{"number":2,"title":"closed","merged":false,"updated_at":"2025-06-02T12:00:00Z","base":{"ref":"release-2.11"}},
+				{"number":1,"title":"too old","merged":true,"merge_commit_sha":"c0","updated_at":"2025-05-01T00:00:00Z","base":{"ref":"release-2.11"}}
+			]`)
+		},
+	})
+	commits, err := client.History("kumahq/kuma", "release-2.11", "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []github.Commit{
+		{Sha: "c3", Message: "feat: x (#3)", PullRequest: &github.PullRequest{Number: 3, Title: "feat: x", Body: "body", Author: "alice"}},
+		{Sha: "c2", Message: "direct push"},
+	}
+	if !reflect.DeepEqual(commits, expected) {
+		t.Errorf("got %+v expected %+v", commits, expected)
+	}
+}
+
+func TestMergeBase(t *testing.T) {
+	client := newServer(t, map[string]http.HandlerFunc{
+		"GET /api/v1/repos/kumahq/kuma/commits": func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("sha") {
+			case "release-2.12":
+				_, _ = io.WriteString(w, `[{"sha":"c2"},{"sha":"c1"},{"sha":"a0"}]`)
+			case "release-2.11":
+				_, _ = io.WriteString(w, `[{"sha":"b1"},{"sha":"a0"}]`)
+			}
+		},
+	})
+	sha, err := client.MergeBase(t.Context(), "kumahq/kuma", "release-2.11", "release-2.12")
+	if err != nil || sha != "a0" {
+		t.Errorf("expected a0 got %q %v", sha, err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	client := newServer(t, map[string]http.HandlerFunc{
+		"GET /api/v1/repos/kumahq/kuma/commits": func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("sha") {
+			case "release-2.11":
+				_, _ = io.WriteString(w, `[{"sha":"b2"},{"sha":"b1"},{"sha":"a0"}]`)
+			case "b1":
+				_, _ = io.WriteString(w, `[{"sha":"b1"},{"sha":"a0"}]`)
+			}
+		},
+		"GET /api/v1/repos/kumahq/kuma/compare/b1...release-2.11": reply(`{"total_commits":1,"commits":[{"sha":"b2"}]}`),
+	})
+	comparison, err := client.Compare(t.Context(), "kumahq/kuma", "b1", "release-2.11")
+	if err != nil || comparison != (github.Comparison{MergeBase: "b1", AheadBy: 1}) {
+		t.Errorf("unexpected comparison %+v %v", comparison, err)
+	}
+}
+
+func TestUpsertRelease(t *testing.T) {
+	var updated map[string]any
+	client := newServer(t, map[string]http.HandlerFunc{
+		"GET /api/v1/repos/kumahq/kuma/releases":   reply(`[{"id":7,"name":"2.11.0","tag_name":"2.11.0","draft":true}]`),
+		"GET /api/v1/repos/kumahq/kuma/releases/7": reply(`{"id":7,"name":"2.11.0","tag_name":"2.11.0","body":"old","draft":true}`),
+		"PATCH /api/v1/repos/kumahq/kuma/releases/7": func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&updated)
+			_, _ = io.WriteString(w, `{}`)
+		},
+	})
+	err := client.UpsertRelease(t.Context(), "kumahq/kuma", "2.11.0", "2.11.0", func(release *github.ReleaseContent) error {
+		if !release.Draft || release.Body != "old" {
+			t.Errorf("unexpected existing release %+v", release)
+		}
+		release.Body = "new"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]any{"tag_name": "2.11.0", "name": "2.11.0", "body": "new", "draft": true}
+	if !reflect.DeepEqual(updated, expected) {
+		t.Errorf("got %v expected %v", updated, expected)
+	}
+}
@@ -0,0 +1,103 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/google/go-github/v90/github"
+)
+
+// Error classes returned (wrapped in an *APIError) by the client, use errors.Is to check them.
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrAuth      = errors.New("authentication failed")
+	ErrRateLimit = errors.New("rate limit exceeded")
+	ErrConflict  = errors.New("conflict")
+	ErrServer    = errors.New("server error")
+)
+
+// APIError is an error response from GitHub (or another forge), Class is one of the Err* error classes or nil if unclassified.
+type APIError struct {
+	StatusCode int
+	Class      error
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("got status: %d body:%s", e.StatusCode, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Class
+}
+
+// classifyStatus maps an http response to an error class.
+func classifyStatus(res *http.Response) error {
+	switch {
+	case res.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimit
+	case res.StatusCode == http.StatusForbidden && res.Header.Get("X-RateLimit-Remaining") == "0":
+		return ErrRateLimit
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return ErrAuth
+	case res.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case res.StatusCode == http.StatusConflict || res.StatusCode == http.StatusUnprocessableEntity:
+		return ErrConflict
+	case res.StatusCode >= 500:
+		return ErrServer
+	}
+	return nil
+}
+
+// CheckResponse returns an *APIError classified by status if res isn't a success, it's meant for REST calls of other forges.
+func CheckResponse(res *http.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	b, _ := io.ReadAll(res.Body)
+	return &APIError{StatusCode: res.StatusCode, Class: classifyStatus(res), Message: string(b)}
+}
+
+// GQLError is an entry of the `errors` field of a GraphQL response.
+type GQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// classifyGQLErrors turns GraphQL errors (which come with a 200 status) into an *APIError.
+func classifyGQLErrors(errs []GQLError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	var class error
+	switch errs[0].Type {
+	case "NOT_FOUND":
+		class = ErrNotFound
+	case "RATE_LIMITED":
+		class = ErrRateLimit
+	case "FORBIDDEN", "INSUFFICIENT_SCOPES":
+		class = ErrAuth
+	}
+	return &APIError{StatusCode: http.StatusOK, Class: class, Message: strconv.Quote(errs[0].Message)}
+}
+
+// wrapRESTError classifies errors returned by go-github.
+func wrapRESTError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var rateErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &rateErr) || errors.As(err, &abuseErr) {
+		return &APIError{StatusCode: http.StatusForbidden, Class: ErrRateLimit, Message: err.Error()}
+	}
+	var respErr *github.ErrorResponse
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		return &APIError{StatusCode: respErr.Response.StatusCode, Class: classifyStatus(respErr.Response), Message: respErr.Message}
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package github
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestClassifyStatus(t *testing.T) {
+	tests := []struct {
+		status    int
+		remaining string
+		expected  error
+	}{
+		{status: 401, expected: ErrAuth},
+		{status: 403, expected: ErrAuth},
+		{status: 403, remaining: "0", expected: ErrRateLimit},
+		{status: 429, expected: ErrRateLimit},
+		{status: 404, expected: ErrNotFound},
+		{status: 422, expected: ErrConflict},
+		{status: 502, expected: ErrServer},
+		{status: 400, expected: nil},
+	}
+	for _, tt := range tests {
+		res := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		if tt.remaining != "" {
+			res.Header.Set("X-RateLimit-Remaining", tt.remaining)
+		}
+		if got := classifyStatus(res); got != tt.expected {
+			t.Errorf("classifyStatus(%d, remaining=%q) = %v, want %v", tt.status, tt.remaining, got, tt.expected)
+		}
+	}
+}
+
+func TestClassifyGQLErrors(t *testing.T) {
+	if err := classifyGQLErrors(nil); err != nil {
+		t.Errorf("expected no error got %v", err)
+	}
+	err := classifyGQLErrors([]GQLError{{Type: "NOT_FOUND", Message: "Could not resolve to a Repository"}})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusOK {
+		t.Errorf("expected an APIError got %#v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package github
+
+import (
+	"context"
+	"io"
+)
+
+// The interfaces below are what commands need from a forge, they let commands run against any backend (or a mock in tests).
+
+// ReleaseLister lists all the releases (including drafts) of a repository, newest first.
+type ReleaseLister interface {
+	Releases(repo string) ([]Release, error)
+}
+
+// ReleaseFinder returns the release (including drafts) named either releaseName or tagName, nil if there's none.
+type ReleaseFinder interface {
+	FindRelease(repo, releaseName, tagName string) (*Release, error)
+}
+
+// HistoryReader returns the commits of branch newest first with the pull request that introduced them.
+// It stops at the first commit whose sha starts with until (if not empty), this commit is excluded.
+type HistoryReader interface {
+	History(repo, branch, until string) ([]Commit, error)
+}
+
+// RefResolver returns the commit a tag points to, it's empty if the tag doesn't exist.
+type RefResolver interface {
+	CommitByRef(repo, tag string) (string, error)
+}
+
+// MergeBaseFinder returns the best common ancestor of two refs.
+type MergeBaseFinder interface {
+	MergeBase(ctx context.Context, repo, base, head string) (string, error)
+}
+
+// Comparer compares head with base, it's meant for counting commits without listing them.
+type Comparer interface {
+	Compare(ctx context.Context, repo, base, head string) (Comparison, error)
+}
+
+// ReleaseUpserter creates the release as a draft if it doesn't exist or updates it with what contentModifier sets.
+type ReleaseUpserter interface {
+	UpsertRelease(ctx context.Context, repo, releaseName, tagName string, contentModifier func(*ReleaseContent) error) error
+}
+
+// AssetReader lists and downloads the assets attached to a release.
+type AssetReader interface {
+	ReleaseAssets(ctx context.Context, repo string, releaseId int) ([]Asset, error)
+	DownloadReleaseAsset(ctx context.Context, repo string, assetId int64) (io.ReadCloser, error)
+}
+
+// Forge is everything release-tool needs from a forge.
+type Forge interface {
+	ReleaseLister
+	ReleaseFinder
+	HistoryReader
+	RefResolver
+	MergeBaseFinder
+	Comparer
+	ReleaseUpserter
+}
+
+var (
+	_ Forge       = GQLClient{}
+	_ AssetReader = GQLClient{}
+)
+
+// ReleaseContent is the part of a release UpsertRelease lets callers change.
+type ReleaseContent struct {
+	Name  string
+	Tag   string
+	Body  string
+	Draft bool
+}
+
+// Commit is a commit of a branch history with the merged pull request that introduced it.
+type Commit struct {
+	Sha     string
+	Message string
+	// PullRequest is nil if the commit wasn't introduced by a pull request (e.g. a direct push).
+	PullRequest *PullRequest
+}
+
+// Comparison is the result of comparing head with base.
+type Comparison struct {
+	MergeBase string
+	// AheadBy is the number of commits of head that aren't in base.
+	AheadBy int
+}
+
+type PullRequest struct {
+	Number int
+	Title  string
+	Body   string
+	Author string
+}
+
+type Asset struct {
+	Id   int64
+	Name string
+}
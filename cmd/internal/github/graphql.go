@@ -2,23 +2,24 @@ package github
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"regexp"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/Masterminds/semver/v3"
 	"github.com/google/go-github/v90/github"
 	"golang.org/x/net/http2"
 )
 
 type GQLOutput struct {
-	Data GQLData `json:"data"`
+	Data   GQLData    `json:"data"`
+	Errors []GQLError `json:"errors,omitempty"`
 }
 type GQLData struct {
 	Repository GQLRepo `json:"repository"`
@@ -33,14 +34,15 @@ type GQLRepo struct {
 	Ref      GQLRef           `json:"ref"`
 	Object   GQLObjectRepo    `json:"object"`
 	Releases GQLObjectRelease `json:"releases"`
+	Release  *Release         `json:"release"`
 }
 
 type GQLObjectRelease struct {
-	Nodes    []GQLRelease `json:"nodes"`
-	PageInfo GQLPageInfo  `json:"pageInfo"`
+	Nodes    []Release   `json:"nodes"`
+	PageInfo GQLPageInfo `json:"pageInfo"`
 }
 
-type GQLRelease struct {
+type Release struct {
 	Name         string    `json:"name"`
 	CreatedAt    time.Time `json:"createdAt"`
 	PublishedAt  time.Time `json:"publishedAt"`
@@ -51,49 +53,11 @@ type GQLRelease struct {
 	IsLatest     bool      `json:"isLatest"`
 }
 
-func (r GQLRelease) SemVer() *semver.Version {
-	return semver.MustParse(strings.TrimPrefix(r.Name, "v"))
-}
-
 // IsReleased returns if the release in not prerelease not a draft
-func (r GQLRelease) IsReleased() bool {
+func (r Release) IsReleased() bool {
 	return !r.IsDraft && !r.IsPrerelease
 }
 
-var releasedOnRegexp = regexp.MustCompile("^> Released on ([0-9]{4}/[0-9]{2}/[0-9]{2})")
-
-// ExtractReleaseDate returns the date this was published, if there's a `> Released on YYYY/MM/DD` in the description it uses this,
-// otherwise it uses the PublishedAt data from Github.
-func (r GQLRelease) ExtractReleaseDate() (time.Time, error) {
-	res := releasedOnRegexp.FindStringSubmatch(r.Description)
-	if len(res) == 2 {
-		return time.Parse("2006/01/02", res[1])
-	}
-	return r.PublishedAt, nil
-}
-
-func (r GQLRelease) IsLTS() bool {
-	return regexp.MustCompile("^> LTS").MatchString(r.Description)
-}
-
-// ExtendedMonths returns the number of additional months this release's lifetime is extended by,
-// as specified by a `> ExtensionMonths: N` line in the description. Returns 0 if not set.
-func (r GQLRelease) ExtendedMonths() int {
-	res := regexp.MustCompile(`(?m)^> ExtensionMonths: ([0-9]+)`).FindStringSubmatch(r.Description)
-	if len(res) == 2 {
-		n, _ := strconv.Atoi(res[1])
-		return n
-	}
-	return 0
-}
-
-// Branch branch that this release was first on
-func (r GQLRelease) Branch() string {
-	// In theory we could extract this from the tag but let's keep this simple
-	v := r.SemVer()
-	return fmt.Sprintf("release-%d.%d", v.Major(), v.Minor())
-}
-
 type GQLObjectRepo struct {
 	History GQLHistoryRepo `json:"history"`
 }
@@ -139,10 +103,15 @@ func (r GQLRefTarget) Commit() string {
 	return r.CommitUrl[strings.LastIndex(r.CommitUrl, "/")+1:]
 }
 
+const defaultAPIURL = "https://api.github.com/"
+
 type GQLClient struct {
 	Token      string
 	Cl         *github.Client
 	httpClient *http.Client
+	graphqlURL string
+	// restHTTPClient follows the redirects of asset downloads, http.DefaultClient when nil.
+	restHTTPClient *http.Client
 }
 
 func SplitRepo(repo string) (string, string) {
@@ -158,92 +127,257 @@ func NewGQLClient(useGHAuth bool) (*GQLClient, error) {
 		return nil, err
 	}
 
-	cl, err := github.NewClient(github.WithAuthToken(token))
+	return newGQLClient(token, defaultAPIURL, newGraphQLHTTPClient(nil), nil)
+}
+
+// NewRecordingGQLClient is like NewGQLClient but it also saves every exchange with GitHub in dir (with the token redacted).
+// The recording can be served offline with NewReplayGQLClient.
+func NewRecordingGQLClient(useGHAuth bool, dir string) (*GQLClient, error) {
+	token, err := getGitHubToken(useGHAuth)
 	if err != nil {
 		return nil, err
 	}
+	rec, err := newRecorder(dir, token)
+	if err != nil {
+		return nil, err
+	}
+	return newGQLClient(token, defaultAPIURL, newGraphQLHTTPClient(rec.wrap), &http.Client{Transport: rec.wrap(http.DefaultTransport)})
+}
 
-	// Configure HTTP client with HTTP/2-specific timeouts for large GraphQL queries
-	// GitHub's GraphQL API uses HTTP/2, which requires http2.Transport for proper timeout handling
-	// ReadIdleTimeout prevents stream cancellation during long-running queries (500+ commits)
-	// by sending ping frames to keep the connection alive
-	httpClient := &http.Client{
-		Timeout: 5 * time.Minute,
-		Transport: &http2.Transport{
-			ReadIdleTimeout: 5 * time.Minute,
-			PingTimeout:     30 * time.Second,
-		},
+// NewReplayGQLClient creates a client serving the exchanges recorded in dir by NewRecordingGQLClient, it never hits the network.
+func NewReplayGQLClient(dir string) (*GQLClient, error) {
+	rep, err := newReplayer(dir)
+	if err != nil {
+		return nil, err
 	}
+	httpClient := &http.Client{Transport: rep}
+	return newGQLClient(redacted, defaultAPIURL, httpClient, httpClient)
+}
 
-	return &GQLClient{Token: token, Cl: cl, httpClient: httpClient}, nil
+// newGraphQLHTTPClient configures the HTTP/2 client of GraphQL queries, queries are paged so Timeout only bounds a single page or batch
+// and ReadIdleTimeout sends ping frames to detect dead connections instead of waiting for the timeout.
+// wrap if not nil is applied to the transport (e.g. to record exchanges).
+func newGraphQLHTTPClient(wrap func(http.RoundTripper) http.RoundTripper) *http.Client {
+	var transport http.RoundTripper = &http2.Transport{
+		ReadIdleTimeout: 30 * time.Second,
+		PingTimeout:     15 * time.Second,
+	}
+	if wrap != nil {
+		transport = wrap(transport)
+	}
+	return &http.Client{
+		Timeout:   time.Minute,
+		Transport: transport,
+	}
 }
 
-func (c GQLClient) ReleaseGraphQL(repo string) ([]GQLRelease, error) {
-	owner, name := SplitRepo(repo)
-	var all []GQLRelease
-	var res GQLOutput
-	var err error
+// NewGQLClientForURL creates a client for a GitHub compatible API at apiURL (e.g. GitHub Enterprise or a fake server in tests).
+// The GraphQL endpoint is `<apiURL>/graphql` and httpClient is used for both GraphQL and REST calls.
+func NewGQLClientForURL(token string, apiURL string, httpClient *http.Client) (*GQLClient, error) {
+	return newGQLClient(token, apiURL, httpClient, httpClient)
+}
 
-	for {
-		cursorStr := ""
-		if res.Data.Repository.Releases.PageInfo.EndCursor != "" {
-			cursorStr = fmt.Sprintf(`after: "%s",`, res.Data.Repository.Releases.PageInfo.EndCursor)
-		}
+func newGQLClient(token string, apiURL string, graphqlHTTPClient *http.Client, restHTTPClient *http.Client) (*GQLClient, error) {
+	if !strings.HasSuffix(apiURL, "/") {
+		apiURL += "/"
+	}
+	opts := []github.ClientOptionsFunc{github.WithAuthToken(token)}
+	if restHTTPClient != nil {
+		opts = append(opts, github.WithHTTPClient(restHTTPClient))
+	}
+	if apiURL != defaultAPIURL {
+		opts = append(opts, github.WithURLs(&apiURL, &apiURL))
+	}
+	cl, err := github.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &GQLClient{Token: token, Cl: cl, httpClient: graphqlHTTPClient, graphqlURL: apiURL + "graphql", restHTTPClient: restHTTPClient}, nil
+}
 
-		query := fmt.Sprintf(`
-query($name: String!, $owner: String!) {
-  repository(owner: $owner, name: $name) {
-    releases(first: 100, %s orderBy: {field: CREATED_AT, direction: DESC}) {
-      nodes {
+// releaseFields are the fields of a release node, the description is skipped when $skipDescription is true.
+const releaseFields = `
         name
         createdAt
         publishedAt
         isDraft
         isPrerelease
-        description
+        description @skip(if: $skipDescription)
         databaseId
-        isLatest
+        isLatest`
+
+// ReleaseQueryOptions configures what release queries fetch.
+type ReleaseQueryOptions struct {
+	// SkipDescription doesn't fetch the descriptions (Release.Description is empty), they are by far the largest part of a release.
+	SkipDescription bool
+}
+
+// Releases returns all the releases of repo (including drafts) newest first.
+func (c GQLClient) Releases(repo string) ([]Release, error) {
+	var all []Release
+	err := c.EachRelease(repo, ReleaseQueryOptions{}, func(r Release) (bool, error) {
+		all = append(all, r)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// EachRelease pages through the releases of repo newest first calling fn for each release until it returns false.
+// Pages are only fetched when needed so stopping early saves requests.
+func (c GQLClient) EachRelease(repo string, opts ReleaseQueryOptions, fn func(Release) (bool, error)) error {
+	owner, name := SplitRepo(repo)
+	start := time.Now()
+	pages, count := 0, 0
+	defer func() {
+		slog.Info("fetched releases", "repo", repo, "releases", count, "pages", pages, "duration", time.Since(start))
+	}()
+
+	variables := map[string]interface{}{"owner": owner, "name": name, "after": nil, "skipDescription": opts.SkipDescription}
+	for {
+		res, err := c.graphqlQuery(`
+query($name: String!, $owner: String!, $after: String, $skipDescription: Boolean!) {
+  repository(owner: $owner, name: $name) {
+    releases(first: 100, after: $after, orderBy: {field: CREATED_AT, direction: DESC}) {
+      nodes {`+releaseFields+`
       }
       pageInfo {
         endCursor
         hasNextPage
-        hasPreviousPage
       }
     }
   }
 }
-`, cursorStr)
-		res, err = c.graphqlQuery(query, map[string]interface{}{"owner": owner, "name": name})
+`, variables)
 		if err != nil {
-			return nil, err
+			return err
 		}
-
-		all = append(all, res.Data.Repository.Releases.Nodes...)
-
-		if !res.Data.Repository.Releases.PageInfo.HasNextPage {
-			break
+		pages++
+		for _, r := range res.Data.Repository.Releases.Nodes {
+			count++
+			next, err := fn(r)
+			if err != nil || !next {
+				return err
+			}
 		}
+		pageInfo := res.Data.Repository.Releases.PageInfo
+		if !pageInfo.HasNextPage {
+			return nil
+		}
+		variables["after"] = pageInfo.EndCursor
 	}
+}
 
-	return all, nil
+// ReleaseByTag returns the release of tag, nil if there's none.
+func (c GQLClient) ReleaseByTag(repo, tag string, opts ReleaseQueryOptions) (*Release, error) {
+	owner, name := SplitRepo(repo)
+	res, err := c.graphqlQuery(`
+query($name: String!, $owner: String!, $tag: String!, $skipDescription: Boolean!) {
+  repository(owner: $owner, name: $name) {
+    release(tagName: $tag) {`+releaseFields+`
+    }
+  }
+}
+`, map[string]interface{}{"owner": owner, "name": name, "tag": tag, "skipDescription": opts.SkipDescription})
+	if err != nil {
+		return nil, err
+	}
+	return res.Data.Repository.Release, nil
 }
 
+const (
+	// historyPageSize is the number of commits per page of history, pages only have oids and messages so they're cheap.
+	historyPageSize = 100
+	// prBatchSize is the number of commits whose pull requests are fetched in a single aliased query.
+	prBatchSize = 20
+	// prWorkers is the maximum number of pull requests queries in flight.
+	prWorkers = 4
+)
+
+// HistoryGraphQl returns the commits of branch (newest first) with their associated pull requests until the commit starting with commitLimit (excluded).
+// Pages of commits are fetched sequentially while the pull requests of the commits already fetched are looked up in batches by a pool of workers.
 func (c GQLClient) HistoryGraphQl(repo, branch, commitLimit string) ([]GQLCommit, error) {
 	owner, name := SplitRepo(repo)
-	var out []GQLCommit
-	var err error
-	var res GQLOutput
-	for {
-		cursorStr := "(first: 50)"
-		if res.Data.Repository.Object.History.PageInfo.EndCursor != "" {
-			cursorStr = fmt.Sprintf(`(first: 50, after: "%s")`, res.Data.Repository.Object.History.PageInfo.EndCursor)
+	start := time.Now()
+	var pages [][]GQLCommit
+	batches := 0
+
+	var mu sync.Mutex
+	var lookupErr error
+	sem := make(chan struct{}, prWorkers)
+	wg := sync.WaitGroup{}
+
+	err := func() error {
+		cursor := ""
+		for {
+			// Stop fetching pages as soon as a pull requests query failed, the history is going to be discarded anyway
+			mu.Lock()
+			failed := lookupErr != nil
+			mu.Unlock()
+			if failed {
+				return nil
+			}
+			page, err := c.historyPage(owner, name, branch, cursor)
+			if err != nil {
+				return err
+			}
+			done := !page.PageInfo.HasNextPage
+			commits := page.Nodes
+			for i, commit := range commits {
+				if commitLimit != "" && strings.HasPrefix(commit.Oid, commitLimit) {
+					commits, done = commits[:i], true
+					break
+				}
+			}
+			pages = append(pages, commits)
+			for i := 0; i < len(commits); i += prBatchSize {
+				batch := commits[i:min(i+prBatchSize, len(commits))]
+				batches++
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					sem <- struct{}{}
+					defer func() { <-sem }()
+					if err := c.associatedPullRequests(owner, name, batch); err != nil {
+						mu.Lock()
+						lookupErr = cmp.Or(lookupErr, err)
+						mu.Unlock()
+					}
+				}()
+			}
+			if done {
+				return nil
+			}
+			cursor = page.PageInfo.EndCursor
 		}
-		res, err = c.graphqlQuery(fmt.Sprintf(`
-query($name: String!, $owner: String!, $branch: String!) {
+	}()
+	wg.Wait()
+	if err = cmp.Or(err, lookupErr); err != nil {
+		return nil, err
+	}
+
+	var out []GQLCommit
+	for _, page := range pages {
+		out = append(out, page...)
+	}
+	slog.Info("fetched history", "repo", repo, "branch", branch, "commits", len(out), "pages", len(pages), "prBatches", batches, "duration", time.Since(start))
+	return out, nil
+}
+
+// historyPage returns a page of commits of branch without their pull requests.
+func (c GQLClient) historyPage(owner, name, branch, cursor string) (GQLHistoryRepo, error) {
+	variables := map[string]interface{}{"owner": owner, "name": name, "branch": branch, "after": nil}
+	if cursor != "" {
+		variables["after"] = cursor
+	}
+	res, err := c.graphqlQuery(fmt.Sprintf(`
+query($name: String!, $owner: String!, $branch: String!, $after: String) {
   repository(owner: $owner, name: $name) {
     object(expression: $branch) {
       ... on Commit {
-        history%s {
+        history(first: %d, after: $after) {
           pageInfo {
             hasNextPage
             endCursor
@@ -251,49 +385,94 @@ query($name: String!, $owner: String!, $branch: String!) {
           nodes {
             oid
             message
-            associatedPullRequests(first: 100) {
-              nodes {
-                author {
-                  login
-                }
-                number
-                title
-                body
-                merged
-                mergeCommit {
-                  oid
-                }
-              }
-            }
           }
         }
       }
     }
   }
 }
-`, cursorStr), map[string]interface{}{"owner": owner, "name": name, "branch": branch})
-		if err != nil {
-			return out, err
+`, historyPageSize), variables)
+	return res.Data.Repository.Object.History, err
+}
+
+// associatedPullRequests fills the pull requests of commits with a single query using an alias per commit.
+func (c GQLClient) associatedPullRequests(owner, name string, commits []GQLCommit) error {
+	b := &strings.Builder{}
+	b.WriteString("query($name: String!, $owner: String!) {\n  repository(owner: $owner, name: $name) {\n")
+	for i, commit := range commits {
+		_, _ = fmt.Fprintf(b, `    c%d: object(oid: %q) {
+      ... on Commit {
+        associatedPullRequests(first: 100) {
+          nodes {
+            author {
+              login
+            }
+            number
+            title
+            body
+            merged
+            mergeCommit {
+              oid
+            }
+          }
+        }
+      }
+    }
+`, i, commit.Oid)
+	}
+	b.WriteString("  }\n}\n")
+	var out struct {
+		Data struct {
+			Repository map[string]*GQLCommit `json:"repository"`
+		} `json:"data"`
+		Errors []GQLError `json:"errors,omitempty"`
+	}
+	if err := c.graphqlRequest(b.String(), map[string]interface{}{"owner": owner, "name": name}, &out); err != nil {
+		return err
+	}
+	if err := classifyGQLErrors(out.Errors); err != nil {
+		return err
+	}
+	for i := range commits {
+		if res := out.Data.Repository[fmt.Sprintf("c%d", i)]; res != nil {
+			commits[i].AssociatedPullRequests = res.AssociatedPullRequests
 		}
-		for _, r := range res.Data.Repository.Object.History.Nodes {
-			if commitLimit != "" && strings.HasPrefix(r.Oid, commitLimit) {
-				return out, err
+	}
+	return nil
+}
+
+// History implements HistoryReader on top of HistoryGraphQl, the pull request of a commit is the merged one with this commit as merge commit.
+func (c GQLClient) History(repo, branch, until string) ([]Commit, error) {
+	res, err := c.HistoryGraphQl(repo, branch, until)
+	if err != nil {
+		return nil, err
+	}
+	var out []Commit
+	for _, commit := range res {
+		ci := Commit{Sha: commit.Oid, Message: commit.Message}
+		for _, prNode := range commit.AssociatedPullRequests.Nodes {
+			if prNode.Merged && prNode.MergeCommit.Oid == commit.Oid {
+				ci.PullRequest = &PullRequest{Number: prNode.Number, Title: prNode.Title, Body: prNode.Body, Author: prNode.Author.Login}
+				break
 			}
-			out = append(out, r)
-		}
-		if !res.Data.Repository.Object.History.PageInfo.HasNextPage {
-			return out, err
 		}
+		out = append(out, ci)
 	}
+	return out, nil
 }
 
 func (c GQLClient) MergeBase(ctx context.Context, repo, base, head string) (string, error) {
+	comparison, err := c.Compare(ctx, repo, base, head)
+	return comparison.MergeBase, err
+}
+
+func (c GQLClient) Compare(ctx context.Context, repo, base, head string) (Comparison, error) {
 	owner, name := SplitRepo(repo)
-	comparison, _, err := c.Cl.Repositories.CompareCommits(ctx, owner, name, base, head, nil)
+	comparison, _, err := c.Cl.Repositories.CompareCommits(ctx, owner, name, base, head, &github.ListOptions{PerPage: 1})
 	if err != nil {
-		return "", err
+		return Comparison{}, wrapRESTError(err)
 	}
-	return comparison.GetMergeBaseCommit().GetSHA(), nil
+	return Comparison{MergeBase: comparison.GetMergeBaseCommit().GetSHA(), AheadBy: comparison.GetAheadBy()}, nil
 }
 
 func (c GQLClient) CommitByRef(repo, tag string) (string, error) {
@@ -319,34 +498,95 @@ query ($owner: String!, $name: String!, $ref: String!) {
 
 func (c GQLClient) graphqlQuery(query string, variables map[string]interface{}) (GQLOutput, error) {
 	var out GQLOutput
+	if err := c.graphqlRequest(query, variables, &out); err != nil {
+		return out, err
+	}
+	return out, classifyGQLErrors(out.Errors)
+}
+
+// graphqlRequest runs query and decodes the response in out, GraphQL errors are left to the caller.
+func (c GQLClient) graphqlRequest(query string, variables map[string]interface{}, out any) error {
 	var err error
 	b2 := bytes.Buffer{}
 	err = json.NewEncoder(&b2).Encode(map[string]interface{}{"query": query, "variables": variables})
 	if err != nil {
-		return out, err
+		return err
 	}
 	var r *http.Request
-	r, err = http.NewRequest(http.MethodPost, "https://api.github.com/graphql", &b2)
+	r, err = http.NewRequest(http.MethodPost, c.graphqlURL, &b2)
 	if err != nil {
-		return out, err
+		return err
 	}
 	r.Header.Set("Authorization", fmt.Sprintf("bearer %s", c.Token))
 	r.Header.Set("Content-Type", "application/json")
 	var res *http.Response
+	start := time.Now()
 	res, err = c.httpClient.Do(r)
 	if err != nil {
-		return out, err
+		return err
 	}
+	defer func() {
+		slog.Debug("graphql query", "variables", variables, "status", res.StatusCode, "duration", time.Since(start))
+	}()
 	defer func(Body io.ReadCloser) {
 		_ = Body.Close()
 	}(res.Body)
 	if res.StatusCode != 200 {
 		b, _ := io.ReadAll(res.Body)
-		err = fmt.Errorf("got status: %d body:%s", res.StatusCode, b)
-		return out, err
+		return &APIError{StatusCode: res.StatusCode, Class: classifyStatus(res), Message: string(b)}
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// FindRelease returns the release (including drafts) named either releaseName or tagName, nil if there's none.
+// It first looks the release up by tag and only pages through releases if that fails (e.g. drafts whose tag doesn't exist yet).
+// Descriptions aren't fetched.
+func (c GQLClient) FindRelease(repo, releaseName, tagName string) (*Release, error) {
+	opts := ReleaseQueryOptions{SkipDescription: true}
+	release, err := c.ReleaseByTag(repo, tagName, opts)
+	if err != nil || (release != nil && (release.Name == releaseName || release.Name == tagName)) {
+		return release, err
+	}
+	release = nil
+	err = c.EachRelease(repo, opts, func(r Release) (bool, error) {
+		if r.Name == releaseName || r.Name == tagName {
+			release = &r
+			return false, nil
+		}
+		return true, nil
+	})
+	return release, err
+}
+
+// ReleaseAssets lists all the assets attached to a release.
+func (c GQLClient) ReleaseAssets(ctx context.Context, repo string, releaseId int) ([]Asset, error) {
+	owner, name := SplitRepo(repo)
+	var out []Asset
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		assets, res, err := c.Cl.Repositories.ListReleaseAssets(ctx, owner, name, int64(releaseId), opts)
+		if err != nil {
+			return nil, wrapRESTError(err)
+		}
+		for _, a := range assets {
+			out = append(out, Asset{Id: a.GetID(), Name: a.GetName()})
+		}
+		if res.NextPage == 0 {
+			return out, nil
+		}
+		opts.Page = res.NextPage
 	}
-	err = json.NewDecoder(res.Body).Decode(&out)
-	return out, err
+}
+
+// DownloadReleaseAsset streams the content of a release asset, this works for draft releases too.
+func (c GQLClient) DownloadReleaseAsset(ctx context.Context, repo string, assetId int64) (io.ReadCloser, error) {
+	owner, name := SplitRepo(repo)
+	followRedirects := http.DefaultClient
+	if c.restHTTPClient != nil {
+		followRedirects = c.restHTTPClient
+	}
+	rc, _, err := c.Cl.Repositories.DownloadReleaseAsset(ctx, owner, name, assetId, followRedirects)
+	return rc, wrapRESTError(err)
 }
 
 func (c GQLClient) UpsertRelease(
@@ -354,62 +594,59 @@ func (c GQLClient) UpsertRelease(
 	repo string,
 	releaseName string,
 	tagName string,
-	contentModifier func(repositoryRelease *github.RepositoryRelease) error,
+	contentModifier func(release *ReleaseContent) error,
 ) error {
-	releases, err := c.ReleaseGraphQL(repo)
+	existingRelease, err := c.FindRelease(repo, releaseName, tagName)
 	if err != nil {
 		return err
 	}
 
-	var existingRelease *GQLRelease
-
-	for _, r := range releases {
-		if r.Name == releaseName || r.Name == tagName {
-			existingRelease = &r
-			break
-		}
-	}
-
 	owner, name := SplitRepo(repo)
 
 	if existingRelease == nil {
-		releasePayload := &github.RepositoryRelease{
-			Name:    &releaseName,
-			Draft:   true,
-			TagName: tagName,
+		content := &ReleaseContent{
+			Name:  releaseName,
+			Draft: true,
+			Tag:   tagName,
 		}
 
-		err := contentModifier(releasePayload)
+		err := contentModifier(content)
 		if err != nil {
 			return err
 		}
 
 		_, _, err = c.Cl.Repositories.CreateRelease(ctx, owner, name, github.CreateReleaseRequest{
-			TagName: releasePayload.TagName,
-			Name:    releasePayload.Name,
-			Body:    releasePayload.Body,
-			Draft:   github.Ptr(releasePayload.Draft),
+			TagName: content.Tag,
+			Name:    &content.Name,
+			Body:    &content.Body,
+			Draft:   github.Ptr(content.Draft),
 		})
 
-		return err
+		return wrapRESTError(err)
 	}
 
 	releasePayload, _, err := c.Cl.Repositories.GetRelease(ctx, owner, name, int64(existingRelease.Id))
 	if err != nil {
-		return err
+		return wrapRESTError(err)
 	}
 
-	err = contentModifier(releasePayload)
+	content := &ReleaseContent{
+		Name:  releasePayload.GetName(),
+		Tag:   releasePayload.TagName,
+		Body:  releasePayload.GetBody(),
+		Draft: releasePayload.Draft,
+	}
+	err = contentModifier(content)
 	if err != nil {
 		return err
 	}
 
 	_, _, err = c.Cl.Repositories.UpdateRelease(ctx, owner, name, int64(existingRelease.Id), github.UpdateReleaseRequest{
-		TagName: &releasePayload.TagName,
-		Name:    releasePayload.Name,
-		Body:    releasePayload.Body,
-		Draft:   github.Ptr(releasePayload.Draft),
+		TagName: &content.Tag,
+		Name:    &content.Name,
+		Body:    &content.Body,
+		Draft:   github.Ptr(content.Draft),
 	})
 
-	return err
+	return wrapRESTError(err)
 }
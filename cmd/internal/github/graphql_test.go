@@ -0,0 +1,178 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHistoryGraphQl(t *testing.T) {
+	const total = 250
+	objectRegexp := regexp.MustCompile(`(c[0-9]+): object\(oid: "([^"]+)"\)`)
+	var inFlight, maxInFlight, prQueries atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if strings.Contains(req.Query, "history(") {
+			after := 0
+			if v, ok := req.Variables["after"].(string); ok {
+				after, _ = strconv.Atoi(v)
+			}
+			var nodes []map[string]any
+			end := min(after+historyPageSize, total)
+			for i := after; i < end; i++ {
+				nodes = append(nodes, map[string]any{"oid": fmt.Sprintf("sha%03d", i), "message": fmt.Sprintf("commit %d", i)})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"repository": map[string]any{"object": map[string]any{"history": map[string]any{
+				"nodes":    nodes,
+				"pageInfo": map[string]any{"hasNextPage": end < total, "endCursor": strconv.Itoa(end)},
+			}}}}})
+			return
+		}
+		prQueries.Add(1)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		objects := map[string]any{}
+		for _, m := range objectRegexp.FindAllStringSubmatch(req.Query, -1) {
+			i, _ := strconv.Atoi(strings.TrimPrefix(m[2], "sha"))
+			objects[m[1]] = map[string]any{"associatedPullRequests": map[string]any{"nodes": []map[string]any{
+				{"number": i, "title": fmt.Sprintf("pr %d", i), "merged": true, "mergeCommit": map[string]any{"oid": m[2]}},
+			}}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"repository": objects}})
+	}))
+	defer srv.Close()
+	client, err := NewGQLClientForURL("token", srv.URL, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commits, err := client.HistoryGraphQl("kumahq/kuma", "master", "sha210")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 210 {
+		t.Fatalf("expected 210 commits got %d", len(commits))
+	}
+	for i, c := range commits {
+		prs := c.AssociatedPullRequests.Nodes
+		if c.Oid != fmt.Sprintf("sha%03d", i) || len(prs) != 1 || prs[0].Number != i || prs[0].MergeCommit.Oid != c.Oid {
+			t.Fatalf("unexpected commit %d: %+v", i, c)
+		}
+	}
+	// 210 commits are 11 batches of at most prBatchSize commits
+	if prQueries.Load() != 11 {
+		t.Errorf("expected 11 pull requests queries got %d", prQueries.Load())
+	}
+	if maxInFlight.Load() > prWorkers {
+		t.Errorf("expected at most %d pull requests queries in flight got %d", prWorkers, maxInFlight.Load())
+	}
+}
+
+func TestHistoryGraphQlStopsOnPullRequestsError(t *testing.T) {
+	var pages atomic.Int32
+	failed := make(chan struct{})
+	var once sync.Once
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !strings.Contains(req.Query, "history(") {
+			once.Do(func() { close(failed) })
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		after := 0
+		if v, ok := req.Variables["after"].(string); ok {
+			after, _ = strconv.Atoi(v)
+			// Let the failed pull requests query be processed before answering
+			<-failed
+			time.Sleep(100 * time.Millisecond)
+		}
+		pages.Add(1)
+		var nodes []map[string]any
+		for i := after; i < after+historyPageSize; i++ {
+			nodes = append(nodes, map[string]any{"oid": fmt.Sprintf("sha%04d", i)})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"repository": map[string]any{"object": map[string]any{"history": map[string]any{
+			"nodes":    nodes,
+			"pageInfo": map[string]any{"hasNextPage": true, "endCursor": strconv.Itoa(after + historyPageSize)},
+		}}}}})
+	}))
+	defer srv.Close()
+	client, err := NewGQLClientForURL("token", srv.URL, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.HistoryGraphQl("kumahq/kuma", "master", ""); err == nil {
+		t.Fatal("expected an error")
+	}
+	if pages.Load() > 2 {
+		t.Errorf("expected to stop fetching pages after the error got %d pages", pages.Load())
+	}
+}
+
+func TestFindRelease(t *testing.T) {
+	var requests []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req.Variables)
+		if skip, _ := req.Variables["skipDescription"].(bool); !skip {
+			t.Errorf("expected descriptions to be skipped")
+		}
+		if strings.Contains(req.Query, "release(tagName") {
+			// The draft has no tag yet
+			_, _ = w.Write([]byte(`{"data":{"repository":{"release":null}}}`))
+			return
+		}
+		after, _ := req.Variables["after"].(string)
+		switch after {
+		case "":
+			_, _ = w.Write([]byte(`{"data":{"repository":{"releases":{"nodes":[{"name":"2.12.0"},{"name":"2.11.1","isDraft":true,"databaseId":3}],"pageInfo":{"hasNextPage":true,"endCursor":"p2"}}}}}`))
+		case "p2":
+			_, _ = w.Write([]byte(`{"data":{"repository":{"releases":{"nodes":[{"name":"2.11.0","databaseId":2}],"pageInfo":{"hasNextPage":true,"endCursor":"p3"}}}}}`))
+		default:
+			t.Errorf("unexpected cursor %q", after)
+		}
+	}))
+	defer srv.Close()
+	client, err := NewGQLClientForURL("token", srv.URL, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	release, err := client.FindRelease("kumahq/kuma", "2.11.0", "2.11.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if release == nil || release.Id != 2 {
+		t.Fatalf("expected release 2 got %+v", release)
+	}
+	// lookup by tag then 2 pages, the last page isn't fetched
+	if len(requests) != 3 || requests[2]["after"] != "p2" {
+		t.Errorf("unexpected requests %v", requests)
+	}
+}
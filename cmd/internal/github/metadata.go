@@ -0,0 +1,348 @@
+package github
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MetadataFence is the info string of the fenced code block holding the metadata of a release.
+const MetadataFence = "release-metadata"
+
+// ReleaseMetadata is what the body of a release says about its lifecycle, it can be set in 3 ways (later ones win):
+//
+// Blockquote lines at the top of the body (after the front matter if any), `> ExtensionMonths: N` is also read anywhere
+// in the body as releases have historically set it after an intro paragraph:
+//
+//	> Released on 2025/04/14
+//	> LTS
+//	> ExtensionMonths: 6
+//	> EndOfLife: 2027/01/01
+//	> SupportTier: extended
+//	> SecurityOnly
+//
+// A yaml front matter:
+//
+//	---
+//	releaseDate: 2025-04-14
+//	lts: true
+//	---
+//
+// A fenced yaml block anywhere in the body:
+//
+//	```release-metadata
+//	extensionMonths: 6
+//	securityOnly: true
+//	```
+type ReleaseMetadata struct {
+	// ReleaseDate overrides the date the release was published at.
+	ReleaseDate     time.Time
+	LTS             bool
+	ExtensionMonths int
+	// EndOfLife overrides the end of life computed from the release date and lifetime.
+	EndOfLife    time.Time
+	SupportTier  string
+	SecurityOnly bool
+}
+
+// metadataFields are the fields set in a yaml block, nil ones are left untouched.
+type metadataFields struct {
+	ReleaseDate     *string `yaml:"releaseDate"`
+	LTS             *bool   `yaml:"lts"`
+	ExtensionMonths *int    `yaml:"extensionMonths"`
+	EndOfLife       *string `yaml:"endOfLife"`
+	SupportTier     *string `yaml:"supportTier"`
+	SecurityOnly    *bool   `yaml:"securityOnly"`
+}
+
+var (
+	frontMatterRegexp   = regexp.MustCompile(`(?s)^---\r?\n(.*?)\r?\n---[ \t]*(?:\r?\n|$)`)
+	metadataFenceRegexp = regexp.MustCompile("(?ms)^```(?:yaml )?" + MetadataFence + `[ \t]*\r?\n(.*?)^` + "```")
+	blockquoteRegexp    = regexp.MustCompile(`^>\s*(Released on|LTS|ExtensionMonths|EndOfLife|SupportTier|SecurityOnly)\b:?\s*(.*?)\s*$`)
+	// extensionMonthsRegexp matches the extension anywhere in the body, the leading blockquote wins if it also sets it
+	extensionMonthsRegexp = regexp.MustCompile(`(?m)^>\s*ExtensionMonths\b:?[ \t]*([0-9]+)[ \t]*\r?$`)
+)
+
+// ParseReleaseMetadata extracts the metadata of a release from its body.
+func ParseReleaseMetadata(body string) (ReleaseMetadata, error) {
+	var out ReleaseMetadata
+	var frontMatter string
+	if m := frontMatterRegexp.FindStringSubmatchIndex(body); m != nil {
+		frontMatter = body[m[2]:m[3]]
+		body = body[m[1]:]
+	}
+	out.parseBlockquote(body)
+	if frontMatter != "" {
+		if err := out.parseYaml(frontMatter); err != nil {
+			return out, fmt.Errorf("invalid front matter: %w", err)
+		}
+	}
+	for _, m := range metadataFenceRegexp.FindAllStringSubmatch(body, -1) {
+		if err := out.parseYaml(m[1]); err != nil {
+			return out, fmt.Errorf("invalid %s block: %w", MetadataFence, err)
+		}
+	}
+	return out, nil
+}
+
+// parseBlockquote reads the blockquote lines at the top of body, other blockquote lines are ignored but `> ExtensionMonths`.
+// Invalid values are ignored with a warning as they are in releases published long ago, the fields are then left untouched.
+func (m *ReleaseMetadata) parseBlockquote(body string) {
+	if res := extensionMonthsRegexp.FindStringSubmatch(body); res != nil {
+		m.ExtensionMonths, _ = strconv.Atoi(res[1])
+	}
+	for _, line := range strings.Split(strings.TrimLeft(body, "\r\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, ">") {
+			return
+		}
+		res := blockquoteRegexp.FindStringSubmatch(line)
+		if res == nil {
+			continue
+		}
+		var err error
+		switch key, value := res[1], res[2]; key {
+		case "Released on":
+			var date time.Time
+			if date, err = parseMetadataDate(value); err == nil {
+				m.ReleaseDate = date
+			}
+		case "LTS":
+			m.LTS = true
+		case "ExtensionMonths":
+			var months int
+			if months, err = strconv.Atoi(value); err == nil {
+				m.ExtensionMonths = months
+			}
+		case "EndOfLife":
+			var date time.Time
+			if date, err = parseMetadataDate(value); err == nil {
+				m.EndOfLife = date
+			}
+		case "SupportTier":
+			m.SupportTier = value
+		case "SecurityOnly":
+			m.SecurityOnly = true
+		}
+		if err != nil {
+			slog.Warn("ignoring invalid release metadata", "line", line, "error", err)
+		}
+	}
+}
+
+func (m *ReleaseMetadata) parseYaml(block string) error {
+	var fields metadataFields
+	dec := yaml.NewDecoder(strings.NewReader(block))
+	dec.KnownFields(true)
+	if err := dec.Decode(&fields); err != nil {
+		return err
+	}
+	var err error
+	if fields.ReleaseDate != nil {
+		if m.ReleaseDate, err = parseMetadataDate(*fields.ReleaseDate); err != nil {
+			return err
+		}
+	}
+	if fields.EndOfLife != nil {
+		if m.EndOfLife, err = parseMetadataDate(*fields.EndOfLife); err != nil {
+			return err
+		}
+	}
+	if fields.LTS != nil {
+		m.LTS = *fields.LTS
+	}
+	if fields.ExtensionMonths != nil {
+		m.ExtensionMonths = *fields.ExtensionMonths
+	}
+	if fields.SupportTier != nil {
+		m.SupportTier = *fields.SupportTier
+	}
+	if fields.SecurityOnly != nil {
+		m.SecurityOnly = *fields.SecurityOnly
+	}
+	return nil
+}
+
+// parseMetadataDate accepts both `2006/01/02` (historical blockquote format) and `2006-01-02`.
+func parseMetadataDate(s string) (time.Time, error) {
+	if t, err := time.Parse("2006/01/02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
+
+// Metadata parses the metadata in the description of the release.
+func (r Release) Metadata() (ReleaseMetadata, error) {
+	m, err := ParseReleaseMetadata(r.Description)
+	if err != nil {
+		return m, fmt.Errorf("invalid metadata in release %s: %w", r.Name, err)
+	}
+	return m, nil
+}
+
+// ReleaseDate returns the date this was published, the one of the metadata if set otherwise PublishedAt.
+func (r Release) ReleaseDate() (time.Time, error) {
+	m, err := r.Metadata()
+	if err != nil || m.ReleaseDate.IsZero() {
+		return r.PublishedAt, err
+	}
+	return m.ReleaseDate, nil
+}
+
+// MetadataUpdate are the changes to make to the metadata of a release, nil fields are left untouched and zero values remove the field.
+type MetadataUpdate struct {
+	ReleaseDate     *time.Time
+	LTS             *bool
+	ExtensionMonths *int
+	EndOfLife       *time.Time
+	SupportTier     *string
+	SecurityOnly    *bool
+}
+
+// metadataLine is the blockquote line for a field, empty to remove it.
+type metadataLine struct {
+	key  string
+	line string
+}
+
+// lines returns the lines of the fields to update in the order they are written.
+func (u MetadataUpdate) lines() []metadataLine {
+	var out []metadataLine
+	dateLine := func(key string, prefix string, t *time.Time) {
+		if t == nil {
+			return
+		}
+		l := metadataLine{key: key}
+		if !t.IsZero() {
+			l.line = prefix + t.Format("2006/01/02")
+		}
+		out = append(out, l)
+	}
+	dateLine("Released on", "> Released on ", u.ReleaseDate)
+	if u.LTS != nil {
+		l := metadataLine{key: "LTS"}
+		if *u.LTS {
+			l.line = "> LTS"
+		}
+		out = append(out, l)
+	}
+	if u.ExtensionMonths != nil {
+		l := metadataLine{key: "ExtensionMonths"}
+		if *u.ExtensionMonths != 0 {
+			l.line = fmt.Sprintf("> ExtensionMonths: %d", *u.ExtensionMonths)
+		}
+		out = append(out, l)
+	}
+	dateLine("EndOfLife", "> EndOfLife: ", u.EndOfLife)
+	if u.SupportTier != nil {
+		l := metadataLine{key: "SupportTier"}
+		if *u.SupportTier != "" {
+			l.line = "> SupportTier: " + *u.SupportTier
+		}
+		out = append(out, l)
+	}
+	if u.SecurityOnly != nil {
+		l := metadataLine{key: "SecurityOnly"}
+		if *u.SecurityOnly {
+			l.line = "> SecurityOnly"
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+// SetReleaseMetadata returns body with the blockquote lines at its top updated according to update, it's idempotent.
+// It fails if a field is also set in the front matter or a fenced block as the blockquote line would have no effect.
+func SetReleaseMetadata(body string, update MetadataUpdate) (string, error) {
+	prefix := ""
+	if m := frontMatterRegexp.FindStringIndex(body); m != nil {
+		prefix, body = body[:m[1]], body[m[1]:]
+	}
+	rest := strings.TrimLeft(body, "\r\n")
+	prefix += body[:len(body)-len(rest)]
+	lines := strings.Split(rest, "\n")
+	// block is the number of blockquote lines at the top, last the index after the last metadata line
+	block, last := 0, 0
+	for block < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[block]), ">") {
+		if blockquoteRegexp.MatchString(strings.TrimSpace(lines[block])) {
+			last = block + 1
+		}
+		block++
+	}
+	for _, l := range update.lines() {
+		found := false
+		for i := 0; i < len(lines); i++ {
+			if i >= block && l.key != "ExtensionMonths" {
+				break
+			}
+			res := blockquoteRegexp.FindStringSubmatch(strings.TrimSpace(lines[i]))
+			if res == nil || res[1] != l.key {
+				continue
+			}
+			found = true
+			if l.line != "" {
+				lines[i] = l.line
+				continue
+			}
+			lines = append(lines[:i], lines[i+1:]...)
+			if i < block {
+				block--
+			}
+			if i < last {
+				last--
+			}
+			i--
+		}
+		if !found && l.line != "" {
+			lines = append(lines[:last], append([]string{l.line}, lines[last:]...)...)
+			if block == 0 && len(lines) > 1 && lines[1] != "" {
+				// separate the new blockquote from the text
+				lines = append(lines[:1], append([]string{""}, lines[1:]...)...)
+			}
+			block++
+			last++
+		}
+	}
+	if block == 0 && len(lines) > 0 && lines[0] == "" {
+		// all the blockquote was removed
+		lines = lines[1:]
+	}
+	out := prefix + strings.Join(lines, "\n")
+
+	got, err := ParseReleaseMetadata(out)
+	if err != nil {
+		return "", err
+	}
+	want := got
+	applyMetadataUpdate(&want, update)
+	if want != got {
+		return "", fmt.Errorf("metadata is also set in the front matter or a %s block, update it there", MetadataFence)
+	}
+	return out, nil
+}
+
+func applyMetadataUpdate(m *ReleaseMetadata, update MetadataUpdate) {
+	if update.ReleaseDate != nil {
+		m.ReleaseDate = *update.ReleaseDate
+	}
+	if update.LTS != nil {
+		m.LTS = *update.LTS
+	}
+	if update.ExtensionMonths != nil {
+		m.ExtensionMonths = *update.ExtensionMonths
+	}
+	if update.EndOfLife != nil {
+		m.EndOfLife = *update.EndOfLife
+	}
+	if update.SupportTier != nil {
+		m.SupportTier = *update.SupportTier
+	}
+	if update.SecurityOnly != nil {
+		m.SecurityOnly = *update.SecurityOnly
+	}
+}
@@ -0,0 +1,155 @@
+package github
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseReleaseMetadata(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+	for _, c := range []struct {
+		desc     string
+		body     string
+		expected ReleaseMetadata
+		err      bool
+	}{
+		{desc: "empty", body: "## Changelog\n* fix"},
+		{
+			desc:     "blockquote",
+			body:     "> Released on 2025/04/14\n> LTS\n> ExtensionMonths: 3\n> EndOfLife: 2027/01/31\n> SupportTier: extended\n> SecurityOnly\n\nintro",
+			expected: ReleaseMetadata{ReleaseDate: date("2025-04-14"), LTS: true, ExtensionMonths: 3, EndOfLife: date("2027-01-31"), SupportTier: "extended", SecurityOnly: true},
+		},
+		{
+			desc: "blockquote only at the top",
+			body: "intro\n\n> LTS\n",
+		},
+		{
+			desc:     "extension months anywhere",
+			body:     "intro\n\n> ExtensionMonths: 6\n\n## Changelog",
+			expected: ReleaseMetadata{ExtensionMonths: 6},
+		},
+		{
+			desc:     "extension months at the top wins",
+			body:     "> ExtensionMonths: 3\n\nintro\n\n> ExtensionMonths: 6\n",
+			expected: ReleaseMetadata{ExtensionMonths: 3},
+		},
+		{
+			desc:     "other blockquote lines are ignored",
+			body:     "> We are excited to announce\n> LTS\n",
+			expected: ReleaseMetadata{LTS: true},
+		},
+		{
+			desc:     "front matter",
+			body:     "---\nreleaseDate: 2025-04-14\nlts: true\n---\n> ExtensionMonths: 6\n\n## Changelog",
+			expected: ReleaseMetadata{ReleaseDate: date("2025-04-14"), LTS: true, ExtensionMonths: 6},
+		},
+		{
+			desc:     "fenced block wins",
+			body:     "> LTS\n> SupportTier: standard\n\n```release-metadata\nlts: false\nsupportTier: extended\nendOfLife: 2027/01/31\n```\n## Changelog",
+			expected: ReleaseMetadata{SupportTier: "extended", EndOfLife: date("2027-01-31")},
+		},
+		{desc: "invalid blockquote values are ignored", body: "> Released on tomorrow\n> EndOfLife: 2019-13-45\n> ExtensionMonths: six\n> LTS", expected: ReleaseMetadata{LTS: true}},
+		{desc: "invalid date in the front matter", body: "---\nreleaseDate: tomorrow\n---\n", err: true},
+		{desc: "invalid date in a fenced block", body: "```release-metadata\nendOfLife: 2019-13-45\n```", err: true},
+		{desc: "unknown field", body: "```release-metadata\nlst: true\n```", err: true},
+	} {
+		t.Run(c.desc, func(t *testing.T) {
+			res, err := ParseReleaseMetadata(c.body)
+			if (err != nil) != c.err {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !c.err && !reflect.DeepEqual(res, c.expected) {
+				t.Errorf("got %+v expected %+v", res, c.expected)
+			}
+		})
+	}
+}
+
+func TestSetReleaseMetadata(t *testing.T) {
+	yes, no := true, false
+	six, zero := 6, 0
+	tier := "extended"
+	date := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		desc     string
+		body     string
+		update   MetadataUpdate
+		expected string
+		err      bool
+	}{
+		{
+			desc:     "add to a body without metadata",
+			body:     "This is a patch release.\n\n## Changelog\n",
+			update:   MetadataUpdate{LTS: &yes, ExtensionMonths: &six},
+			expected: "> LTS\n> ExtensionMonths: 6\n\nThis is a patch release.\n\n## Changelog\n",
+		},
+		{
+			desc:     "add to an empty body",
+			update:   MetadataUpdate{LTS: &yes},
+			expected: "> LTS\n",
+		},
+		{
+			desc:     "replace and add after existing lines",
+			body:     "> Released on 2024/01/01\n> ExtensionMonths: 3\n> Some announcement\n\nintro",
+			update:   MetadataUpdate{ReleaseDate: &date, ExtensionMonths: &six, SupportTier: &tier},
+			expected: "> Released on 2024/02/01\n> ExtensionMonths: 6\n> SupportTier: extended\n> Some announcement\n\nintro",
+		},
+		{
+			desc:     "already up to date",
+			body:     "> LTS\n> ExtensionMonths: 6\n\nintro",
+			update:   MetadataUpdate{LTS: &yes, ExtensionMonths: &six},
+			expected: "> LTS\n> ExtensionMonths: 6\n\nintro",
+		},
+		{
+			desc:     "remove all lines",
+			body:     "> LTS\n> ExtensionMonths: 6\n\nintro",
+			update:   MetadataUpdate{LTS: &no, ExtensionMonths: &zero},
+			expected: "intro",
+		},
+		{
+			desc:     "extension months after an intro",
+			body:     "intro\n\n> ExtensionMonths: 3\n",
+			update:   MetadataUpdate{ExtensionMonths: &six},
+			expected: "intro\n\n> ExtensionMonths: 6\n",
+		},
+		{
+			desc:     "remove extension months after an intro",
+			body:     "> LTS\n\nintro\n\n> ExtensionMonths: 3\n",
+			update:   MetadataUpdate{ExtensionMonths: &zero},
+			expected: "> LTS\n\nintro\n\n",
+		},
+		{
+			desc:     "after front matter",
+			body:     "---\nsupportTier: standard\n---\nintro",
+			update:   MetadataUpdate{LTS: &yes},
+			expected: "---\nsupportTier: standard\n---\n> LTS\n\nintro",
+		},
+		{
+			desc:   "field set in a fenced block",
+			body:   "intro\n```release-metadata\nlts: false\n```\n",
+			update: MetadataUpdate{LTS: &yes},
+			err:    true,
+		},
+	} {
+		t.Run(c.desc, func(t *testing.T) {
+			res, err := SetReleaseMetadata(c.body, c.update)
+			if (err != nil) != c.err {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if c.err {
+				return
+			}
+			if res != c.expected {
+				t.Errorf("got:\n%q\nexpected:\n%q", res, c.expected)
+			}
+			again, err := SetReleaseMetadata(res, c.update)
+			if err != nil || again != res {
+				t.Errorf("not idempotent got:\n%q\n%v", again, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package github
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const redacted = "REDACTED"
+
+// sensitiveHeaders are never written to a recording.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// exchange is a request to GitHub and its response as saved in a recording, one per file.
+type exchange struct {
+	Request  recordedMessage `json:"request"`
+	Response recordedMessage `json:"response"`
+}
+
+type recordedMessage struct {
+	Method     string      `json:"method,omitempty"`
+	URL        string      `json:"url,omitempty"`
+	StatusCode int         `json:"statusCode,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	// BodyBase64 is used instead of Body for binary content (e.g. release assets).
+	BodyBase64 string `json:"bodyBase64,omitempty"`
+}
+
+func (m *recordedMessage) setBody(b []byte) {
+	if utf8.Valid(b) {
+		m.Body = string(b)
+	} else {
+		m.BodyBase64 = base64.StdEncoding.EncodeToString(b)
+	}
+}
+
+func (m recordedMessage) body() ([]byte, error) {
+	if m.BodyBase64 != "" {
+		return base64.StdEncoding.DecodeString(m.BodyBase64)
+	}
+	return []byte(m.Body), nil
+}
+
+// exchangeKey identifies a request in a recording, the host is ignored so a recording made against GitHub Enterprise can be replayed too.
+func exchangeKey(method string, path string, query string, body []byte) string {
+	return fmt.Sprintf("%s %s?%s\n%s", method, path, query, body)
+}
+
+// recorder saves every exchange going through the transports it wraps in dir, with the token redacted.
+type recorder struct {
+	dir   string
+	token string
+
+	mu  sync.Mutex
+	seq int
+}
+
+func newRecorder(dir string, token string) (*recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	return &recorder{dir: dir, token: token}, nil
+}
+
+func (r *recorder) wrap(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		var reqBody []byte
+		if req.Body != nil {
+			var err error
+			reqBody, err = io.ReadAll(req.Body)
+			_ = req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(reqBody))
+		}
+		res, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		resBody, err := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+		e := exchange{
+			Request:  recordedMessage{Method: req.Method, URL: req.URL.String(), Header: r.redactHeader(req.Header)},
+			Response: recordedMessage{StatusCode: res.StatusCode, Header: r.redactHeader(res.Header)},
+		}
+		e.Request.setBody(r.redact(reqBody))
+		e.Response.setBody(r.redact(resBody))
+		return res, r.save(e)
+	})
+}
+
+func (r *recorder) redact(b []byte) []byte {
+	if r.token == "" {
+		return b
+	}
+	return bytes.ReplaceAll(b, []byte(r.token), []byte(redacted))
+}
+
+func (r *recorder) redactHeader(h http.Header) http.Header {
+	out := http.Header{}
+	for k, values := range h {
+		for _, v := range values {
+			if r.token != "" {
+				v = strings.ReplaceAll(v, r.token, redacted)
+			}
+			out.Add(k, v)
+		}
+	}
+	for _, k := range sensitiveHeaders {
+		if out.Get(k) != "" {
+			out.Set(k, redacted)
+		}
+	}
+	return out
+}
+
+func (r *recorder) save(e exchange) error {
+	b, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	return os.WriteFile(filepath.Join(r.dir, fmt.Sprintf("%04d.json", r.seq)), b, 0o600)
+}
+
+// replayer serves the exchanges of a recording without network access.
+// Identical requests get their responses in the order they were recorded.
+type replayer struct {
+	dir string
+
+	mu        sync.Mutex
+	exchanges map[string][]exchange
+}
+
+func newReplayer(dir string) (*replayer, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recorded exchanges in %s", dir)
+	}
+	sort.Strings(files)
+	r := &replayer{dir: dir, exchanges: map[string][]exchange{}}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var e exchange
+		if err := json.Unmarshal(b, &e); err != nil {
+			return nil, fmt.Errorf("invalid recording %s: %w", f, err)
+		}
+		body, err := e.Request.body()
+		if err != nil {
+			return nil, fmt.Errorf("invalid recording %s: %w", f, err)
+		}
+		req, err := http.NewRequest(e.Request.Method, e.Request.URL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recording %s: %w", f, err)
+		}
+		k := exchangeKey(req.Method, req.URL.Path, req.URL.RawQuery, body)
+		r.exchanges[k] = append(r.exchanges[k], e)
+	}
+	return r, nil
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	k := exchangeKey(req.Method, req.URL.Path, req.URL.RawQuery, reqBody)
+	r.mu.Lock()
+	queue := r.exchanges[k]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("no recorded exchange for %s %s in %s", req.Method, req.URL, r.dir)
+	}
+	e := queue[0]
+	r.exchanges[k] = queue[1:]
+	r.mu.Unlock()
+
+	body, err := e.Response.body()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Response.StatusCode, http.StatusText(e.Response.StatusCode)),
+		StatusCode:    e.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Response.Header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
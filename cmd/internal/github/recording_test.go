@@ -0,0 +1,97 @@
+package github
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	const token = "s3cr3t-token"
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/graphql":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"data":{"repository":{"releases":{"nodes":[{"name":"2.11.0","databaseId":5,"description":"token `+token+` leaked"}],"pageInfo":{"hasNextPage":false}}}}}`)
+		case "/repos/kumahq/kuma/releases/assets/7":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write([]byte{0xff, 0xfe, 0x00, byte(calls)})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+
+	rec, err := newRecorder(dir, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recording, err := newGQLClient(token, srv.URL, &http.Client{Transport: rec.wrap(http.DefaultTransport)}, &http.Client{Transport: rec.wrap(http.DefaultTransport)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := recording.Releases("kumahq/kuma")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recordedAssets [][]byte
+	for range 2 {
+		recordedAssets = append(recordedAssets, downloadAsset(t, recording))
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 3 {
+		t.Fatalf("expected 3 recorded exchanges got %d", len(files))
+	}
+	for _, f := range files {
+		b, _ := os.ReadFile(f)
+		if strings.Contains(string(b), token) {
+			t.Errorf("%s contains the token:\n%s", f, b)
+		}
+	}
+
+	srv.Close()
+	replay, err := NewReplayGQLClient(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := replay.Releases("kumahq/kuma")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The token is redacted in recordings so replayed content differs from what was served.
+	recorded[0].Description = strings.ReplaceAll(recorded[0].Description, token, redacted)
+	if !reflect.DeepEqual(recorded, replayed) {
+		t.Errorf("replayed releases differ got %+v expected %+v", replayed, recorded)
+	}
+	for i := range 2 {
+		if got := downloadAsset(t, replay); !reflect.DeepEqual(got, recordedAssets[i]) {
+			t.Errorf("replayed asset %d differs got %v expected %v", i, got, recordedAssets[i])
+		}
+	}
+	if _, err := replay.Releases("kumahq/kong-mesh"); err == nil || !strings.Contains(err.Error(), "no recorded exchange") {
+		t.Errorf("expected an error for a request that wasn't recorded got %v", err)
+	}
+}
+
+func downloadAsset(t *testing.T, c *GQLClient) []byte {
+	t.Helper()
+	rc, err := c.DownloadReleaseAsset(t.Context(), "kumahq/kuma", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"strings"
@@ -159,7 +160,7 @@ func tryGHAuth() (string, error) {
 		return token, nil
 	}
 
-	_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to get token from gh auth, falling back to environment variables: %v\n", err)
+	slog.Warn("failed to get token from gh auth, falling back to environment variables", "error", err)
 
 	return "", nil
 }
@@ -202,7 +203,7 @@ func tryInteractiveGHAuth(useGHAuth bool) (string, error) {
 		return token, nil
 	}
 
-	_, _ = fmt.Fprintf(os.Stderr, "Warning: failed to get token from gh auth: %v\n", err)
+	slog.Warn("failed to get token from gh auth", "error", err)
 
 	return "", nil
 }
@@ -0,0 +1,95 @@
+package github
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// UnparsablePolicy is what to do with releases whose name isn't a version.
+type UnparsablePolicy string
+
+const (
+	// UnparsableFail returns an error for the first release whose name isn't a version.
+	UnparsableFail UnparsablePolicy = "fail"
+	// UnparsableSkip drops releases whose name isn't a version with a warning.
+	UnparsableSkip UnparsablePolicy = "skip"
+)
+
+// defaultNamePattern is a semver with an optional `v` prefix (e.g. `2.11.0` or `v2.11.8`).
+var defaultNamePattern = regexp.MustCompile(`^v?(.+)$`)
+
+// VersionParser extracts versions from release names.
+type VersionParser struct {
+	// Patterns are tried in order before the default one (an optional `v` prefix), the first capture group
+	// of the first pattern matching is the version (e.g. `^kuma-(.+)$` for helm chart releases).
+	Patterns []*regexp.Regexp
+}
+
+// NewVersionParser compiles patterns, each must have exactly one capture group.
+func NewVersionParser(patterns []string) (VersionParser, error) {
+	var out VersionParser
+	for _, p := range patterns {
+		r, err := regexp.Compile(p)
+		if err != nil {
+			return out, fmt.Errorf("invalid release name pattern %q: %w", p, err)
+		}
+		if r.NumSubexp() != 1 {
+			return out, fmt.Errorf("invalid release name pattern %q: must have exactly one capture group for the version", p)
+		}
+		out.Patterns = append(out.Patterns, r)
+	}
+	return out, nil
+}
+
+// Parse returns the version in name.
+func (p VersionParser) Parse(name string) (*semver.Version, error) {
+	for _, r := range p.Patterns {
+		if m := r.FindStringSubmatch(name); m != nil {
+			return parseVersion(name, m[1])
+		}
+	}
+	if m := defaultNamePattern.FindStringSubmatch(name); m != nil {
+		return parseVersion(name, m[1])
+	}
+	return nil, fmt.Errorf("release %q doesn't match any release name pattern", name)
+}
+
+func parseVersion(name string, version string) (*semver.Version, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("release %q isn't a version: %w", name, err)
+	}
+	return v, nil
+}
+
+// ParsedRelease is a release with the version extracted from its name.
+type ParsedRelease struct {
+	Release
+	Version *semver.Version
+}
+
+// Branch branch that this release was first on
+func (r ParsedRelease) Branch() string {
+	// In theory we could extract this from the tag but let's keep this simple
+	return fmt.Sprintf("release-%d.%d", r.Version.Major(), r.Version.Minor())
+}
+
+// ParseReleases parses the version of all releases keeping their order, policy decides what happens to the ones that can't be parsed.
+func (p VersionParser) ParseReleases(releases []Release, policy UnparsablePolicy) ([]ParsedRelease, error) {
+	out := make([]ParsedRelease, 0, len(releases))
+	for _, r := range releases {
+		v, err := p.Parse(r.Name)
+		if err != nil {
+			if policy != UnparsableSkip {
+				return nil, err
+			}
+			slog.Warn("skipping release", "release", r.Name, "error", err)
+			continue
+		}
+		out = append(out, ParsedRelease{Release: r, Version: v})
+	}
+	return out, nil
+}
@@ -0,0 +1,59 @@
+package github
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVersionParser(t *testing.T) {
+	parser, err := NewVersionParser([]string{`^kuma-(.+)$`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name     string
+		expected string
+	}{
+		{name: "2.11.0", expected: "2.11.0"},
+		{name: "v2.11.8", expected: "2.11.8"},
+		{name: "kuma-2.9.0", expected: "2.9.0"},
+		{name: "2.12.0-rc.1", expected: "2.12.0-rc.1"},
+		{name: "nightly"},
+		{name: "kuma-latest"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			v, err := parser.Parse(c.name)
+			switch {
+			case c.expected == "" && err == nil:
+				t.Errorf("expected an error got %s", v)
+			case c.expected != "" && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case c.expected != "" && v.String() != c.expected:
+				t.Errorf("expected %s got %s", c.expected, v)
+			}
+		})
+	}
+
+	if _, err := NewVersionParser([]string{`^kuma-.+$`}); err == nil {
+		t.Errorf("expected an error for a pattern without capture group")
+	}
+}
+
+func TestParseReleases(t *testing.T) {
+	releases := []Release{{Name: "2.11.0"}, {Name: "nightly"}, {Name: "v2.10.1"}}
+	if _, err := (VersionParser{}).ParseReleases(releases, UnparsableFail); err == nil {
+		t.Errorf("expected an error for nightly")
+	}
+	parsed, err := VersionParser{}.ParseReleases(releases, UnparsableSkip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names, branches []string
+	for _, r := range parsed {
+		names = append(names, r.Name)
+		branches = append(branches, r.Branch())
+	}
+	if !reflect.DeepEqual(names, []string{"2.11.0", "v2.10.1"}) || !reflect.DeepEqual(branches, []string{"release-2.11", "release-2.10"}) {
+		t.Errorf("unexpected releases %v branches %v", names, branches)
+	}
+}
@@ -0,0 +1,78 @@
+package githubfake
+
+import (
+	"bytes"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture is the state served by the fake, it's keyed by repository (`owner/name`).
+type Fixture struct {
+	Repos map[string]*Repo `yaml:"repos"`
+}
+
+type Repo struct {
+	// Releases in any order, the fake sorts them by creation date like GitHub.
+	Releases []*Release `yaml:"releases"`
+	// Branches maps a branch to its history, newest commit first.
+	Branches map[string][]Commit `yaml:"branches"`
+	// Tags maps a tag to the commit it points to.
+	Tags map[string]string `yaml:"tags"`
+	// MergeBases overrides the merge-base computed from the branches history (e.g. for branches that diverged).
+	MergeBases []MergeBase `yaml:"mergeBases"`
+}
+
+type Release struct {
+	Id          int64     `yaml:"id"`
+	Name        string    `yaml:"name"`
+	Tag         string    `yaml:"tag"`
+	CreatedAt   time.Time `yaml:"createdAt"`
+	PublishedAt time.Time `yaml:"publishedAt"`
+	Draft       bool      `yaml:"draft"`
+	Prerelease  bool      `yaml:"prerelease"`
+	Latest      bool      `yaml:"latest"`
+	Body        string    `yaml:"body"`
+	Assets      []Asset   `yaml:"assets"`
+}
+
+type Asset struct {
+	Id      int64  `yaml:"id"`
+	Name    string `yaml:"name"`
+	Content string `yaml:"content"`
+}
+
+type Commit struct {
+	Oid          string        `yaml:"oid"`
+	Message      string        `yaml:"message"`
+	PullRequests []PullRequest `yaml:"pullRequests"`
+}
+
+type PullRequest struct {
+	Number int    `yaml:"number"`
+	Title  string `yaml:"title"`
+	Body   string `yaml:"body"`
+	Author string `yaml:"author"`
+	Merged bool   `yaml:"merged"`
+	// MergeCommit defaults to the commit the pull request is attached to when it's merged.
+	MergeCommit string `yaml:"mergeCommit"`
+}
+
+type MergeBase struct {
+	Base   string `yaml:"base"`
+	Head   string `yaml:"head"`
+	Commit string `yaml:"commit"`
+}
+
+// LoadFixture reads a fixture from a yaml file.
+func LoadFixture(path string) (Fixture, error) {
+	var out Fixture
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return out, err
+	}
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	d.KnownFields(true)
+	return out, d.Decode(&out)
+}
@@ -0,0 +1,481 @@
+// Package githubfake is an in memory GitHub serving the GraphQL and REST calls of the github package from a Fixture.
+// It's meant to run commands end to end in tests without network access.
+package githubfake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gogithub "github.com/google/go-github/v90/github"
+
+	"github.com/kumahq/ci-tools/cmd/internal/github"
+)
+
+// Token is the token accepted by the fake, requests without an Authorization header are rejected.
+const Token = "fake-token"
+
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	fixture Fixture
+	nextId  int64
+}
+
+// New starts a fake serving fixture, it's closed at the end of the test.
+func New(t testing.TB, fixture Fixture) *Server {
+	t.Helper()
+	s := &Server{fixture: fixture, nextId: 1000}
+	if s.fixture.Repos == nil {
+		s.fixture.Repos = map[string]*Repo{}
+	}
+	for _, r := range s.fixture.Repos {
+		for _, rel := range r.Releases {
+			s.nextId = max(s.nextId, rel.Id+1)
+		}
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Load starts a fake serving the fixture at path.
+func Load(t testing.TB, path string) *Server {
+	t.Helper()
+	fixture, err := LoadFixture(path)
+	if err != nil {
+		t.Fatalf("failed to load fixture %s: %v", path, err)
+	}
+	return New(t, fixture)
+}
+
+// GQLClient returns a client talking to the fake.
+func (s *Server) GQLClient() (*github.GQLClient, error) {
+	return github.NewGQLClientForURL(Token, s.URL, s.Client())
+}
+
+// Release returns a copy of the release named name in repo, nil if there's none.
+// It's useful to check what a command created or updated.
+func (s *Server) Release(repo, name string) *Release {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.fixture.Repos[repo]
+	if r == nil {
+		return nil
+	}
+	for _, rel := range r.Releases {
+		if rel.Name == name {
+			out := *rel
+			return &out
+		}
+	}
+	return nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") == "" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Requires authentication"})
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Path == "/graphql" && r.Method == http.MethodPost {
+		s.serveGraphQL(w, r)
+		return
+	}
+	// Paths are /repos/{owner}/{name}/...
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] != "repos" {
+		notFound(w)
+		return
+	}
+	repo := s.fixture.Repos[parts[1]+"/"+parts[2]]
+	if repo == nil {
+		notFound(w)
+		return
+	}
+	switch p := parts[3:]; {
+	case r.Method == http.MethodGet && len(p) == 2 && p[0] == "compare":
+		s.compare(w, repo, p[1])
+	case r.Method == http.MethodPost && len(p) == 1 && p[0] == "releases":
+		s.createRelease(w, r, repo)
+	case len(p) == 3 && p[0] == "releases" && p[1] == "assets" && r.Method == http.MethodGet:
+		s.downloadAsset(w, r, repo, p[2])
+	case len(p) == 2 && p[0] == "releases":
+		rel := repo.releaseById(p[1])
+		switch {
+		case rel == nil:
+			notFound(w)
+		case r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, rel.toREST())
+		case r.Method == http.MethodPatch:
+			s.updateRelease(w, r, rel)
+		default:
+			notFound(w)
+		}
+	case len(p) == 3 && p[0] == "releases" && p[2] == "assets" && r.Method == http.MethodGet:
+		rel := repo.releaseById(p[1])
+		if rel == nil {
+			notFound(w)
+			return
+		}
+		var out []*gogithub.ReleaseAsset
+		for _, a := range rel.Assets {
+			out = append(out, a.toREST())
+		}
+		writeJSON(w, http.StatusOK, out)
+	default:
+		notFound(w)
+	}
+}
+
+func (s *Server) compare(w http.ResponseWriter, repo *Repo, baseHead string) {
+	base, head, ok := strings.Cut(baseHead, "...")
+	if !ok {
+		notFound(w)
+		return
+	}
+	mergeBase := repo.mergeBase(base, head)
+	if mergeBase == "" {
+		notFound(w)
+		return
+	}
+	aheadBy := 0
+	_, headHistory := repo.resolve(head)
+	for _, c := range headHistory {
+		if c.Oid == mergeBase {
+			break
+		}
+		aheadBy++
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"merge_base_commit": map[string]string{"sha": mergeBase}, "ahead_by": aheadBy})
+}
+
+func (s *Server) createRelease(w http.ResponseWriter, r *http.Request, repo *Repo) {
+	var req gogithub.CreateReleaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
+	for _, rel := range repo.Releases {
+		if rel.Tag == req.TagName {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "Validation Failed: tag_name already_exists"})
+			return
+		}
+	}
+	rel := &Release{
+		Id:         s.nextId,
+		Name:       req.GetName(),
+		Tag:        req.TagName,
+		CreatedAt:  now(),
+		Draft:      req.GetDraft(),
+		Prerelease: req.GetPrerelease(),
+		Body:       req.GetBody(),
+	}
+	if !rel.Draft {
+		rel.PublishedAt = rel.CreatedAt
+	}
+	s.nextId++
+	repo.Releases = append(repo.Releases, rel)
+	writeJSON(w, http.StatusCreated, rel.toREST())
+}
+
+func (s *Server) updateRelease(w http.ResponseWriter, r *http.Request, rel *Release) {
+	var req gogithub.UpdateReleaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
+	if req.TagName != nil {
+		rel.Tag = *req.TagName
+	}
+	if req.Name != nil {
+		rel.Name = *req.Name
+	}
+	if req.Body != nil {
+		rel.Body = *req.Body
+	}
+	if req.Prerelease != nil {
+		rel.Prerelease = *req.Prerelease
+	}
+	if req.Draft != nil {
+		if rel.Draft && !*req.Draft {
+			rel.PublishedAt = now()
+		}
+		rel.Draft = *req.Draft
+	}
+	writeJSON(w, http.StatusOK, rel.toREST())
+}
+
+func (s *Server) downloadAsset(w http.ResponseWriter, r *http.Request, repo *Repo, id string) {
+	for _, rel := range repo.Releases {
+		for _, a := range rel.Assets {
+			if strconv.FormatInt(a.Id, 10) != id {
+				continue
+			}
+			if r.Header.Get("Accept") == "application/octet-stream" {
+				w.Header().Set("Content-Type", "application/octet-stream")
+				_, _ = w.Write([]byte(a.Content))
+				return
+			}
+			writeJSON(w, http.StatusOK, a.toREST())
+			return
+		}
+	}
+	notFound(w)
+}
+
+type graphqlRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+var (
+	firstRegexp  = regexp.MustCompile(`(releases|history)\(first: ([0-9]+)`)
+	objectRegexp = regexp.MustCompile(`([a-zA-Z0-9_]+): object\(oid: "([^"]+)"\)`)
+)
+
+func (s *Server) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphqlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": err.Error()})
+		return
+	}
+	owner, _ := req.Variables["owner"].(string)
+	name, _ := req.Variables["name"].(string)
+	repo := s.fixture.Repos[owner+"/"+name]
+	if repo == nil {
+		writeGraphQLError(w, "NOT_FOUND", fmt.Sprintf("Could not resolve to a Repository with the name '%s/%s'.", owner, name))
+		return
+	}
+	first, after := 100, 0
+	if m := firstRegexp.FindStringSubmatch(req.Query); m != nil {
+		first, _ = strconv.Atoi(m[2])
+	}
+	if v, ok := req.Variables["after"].(string); ok && v != "" {
+		after, _ = strconv.Atoi(v)
+	}
+	skipDescription, _ := req.Variables["skipDescription"].(bool)
+	switch {
+	case strings.Contains(req.Query, "releases("):
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"repository": map[string]any{"releases": repo.releasesPage(first, after, skipDescription)}}})
+	case strings.Contains(req.Query, "release(tagName"):
+		// Like GitHub drafts aren't found by tag
+		tag, _ := req.Variables["tag"].(string)
+		var release any
+		for _, rel := range repo.Releases {
+			if rel.Tag == tag && !rel.Draft {
+				release = rel.toGraphQL(skipDescription)
+				break
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"repository": map[string]any{"release": release}}})
+	case strings.Contains(req.Query, "object(oid:"):
+		objects := map[string]any{}
+		for _, m := range objectRegexp.FindAllStringSubmatch(req.Query, -1) {
+			objects[m[1]] = nil
+			if c := repo.commit(m[2]); c != nil {
+				objects[m[1]] = map[string]any{"associatedPullRequests": map[string]any{"nodes": pullRequestNodes(*c)}}
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"repository": objects}})
+	case strings.Contains(req.Query, "history"):
+		branch, _ := req.Variables["branch"].(string)
+		commits, ok := repo.Branches[branch]
+		if !ok {
+			writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"repository": map[string]any{"object": nil}}})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"repository": map[string]any{"object": map[string]any{"history": historyPage(commits, first, after, strings.Contains(req.Query, "associatedPullRequests"))}}}})
+	case strings.Contains(req.Query, "ref(qualifiedName"):
+		ref, _ := req.Variables["ref"].(string)
+		var target any
+		if oid, ok := repo.Tags[strings.TrimPrefix(ref, "refs/tags/")]; ok {
+			target = map[string]any{"target": map[string]string{"oid": oid, "commitUrl": fmt.Sprintf("https://github.com/%s/%s/commit/%s", owner, name, oid)}}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"repository": map[string]any{"ref": target}}})
+	default:
+		writeGraphQLError(w, "UNSUPPORTED", "query not supported by githubfake")
+	}
+}
+
+func (r *Repo) releasesPage(first, after int, skipDescription bool) map[string]any {
+	releases := slices.Clone(r.Releases)
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].CreatedAt.After(releases[j].CreatedAt)
+	})
+	var nodes []map[string]any
+	end := min(after+first, len(releases))
+	for _, rel := range releases[min(after, end):end] {
+		nodes = append(nodes, rel.toGraphQL(skipDescription))
+	}
+	return map[string]any{"nodes": nodes, "pageInfo": pageInfo(end, len(releases))}
+}
+
+func historyPage(commits []Commit, first, after int, withPullRequests bool) map[string]any {
+	var nodes []map[string]any
+	end := min(after+first, len(commits))
+	for _, c := range commits[min(after, end):end] {
+		node := map[string]any{"oid": c.Oid, "message": c.Message}
+		if withPullRequests {
+			node["associatedPullRequests"] = map[string]any{"nodes": pullRequestNodes(c)}
+		}
+		nodes = append(nodes, node)
+	}
+	return map[string]any{"nodes": nodes, "pageInfo": pageInfo(end, len(commits))}
+}
+
+func pullRequestNodes(c Commit) []map[string]any {
+	var prs []map[string]any
+	for _, pr := range c.PullRequests {
+		mergeCommit := pr.MergeCommit
+		if mergeCommit == "" && pr.Merged {
+			mergeCommit = c.Oid
+		}
+		prs = append(prs, map[string]any{
+			"author":      map[string]string{"login": pr.Author},
+			"number":      pr.Number,
+			"title":       pr.Title,
+			"body":        pr.Body,
+			"merged":      pr.Merged,
+			"mergeCommit": map[string]string{"oid": mergeCommit},
+		})
+	}
+	return prs
+}
+
+func (r *Repo) commit(oid string) *Commit {
+	for _, commits := range r.Branches {
+		for i := range commits {
+			if commits[i].Oid == oid {
+				return &commits[i]
+			}
+		}
+	}
+	return nil
+}
+
+func pageInfo(end, total int) map[string]any {
+	return map[string]any{"endCursor": strconv.Itoa(end), "hasNextPage": end < total, "hasPreviousPage": false}
+}
+
+// resolve returns the commit a branch, a tag or a sha points to and the history starting from it.
+func (r *Repo) resolve(ref string) (string, []Commit) {
+	if commits, ok := r.Branches[ref]; ok && len(commits) > 0 {
+		return commits[0].Oid, commits
+	}
+	sha := ref
+	if oid, ok := r.Tags[ref]; ok {
+		sha = oid
+	}
+	for _, commits := range r.Branches {
+		for i, c := range commits {
+			if c.Oid == sha {
+				return sha, commits[i:]
+			}
+		}
+	}
+	return sha, nil
+}
+
+func (r *Repo) mergeBase(base, head string) string {
+	for _, m := range r.MergeBases {
+		if m.Base == base && m.Head == head {
+			return m.Commit
+		}
+	}
+	_, baseHistory := r.resolve(base)
+	_, headHistory := r.resolve(head)
+	inBase := map[string]bool{}
+	for _, c := range baseHistory {
+		inBase[c.Oid] = true
+	}
+	for _, c := range headHistory {
+		if inBase[c.Oid] {
+			return c.Oid
+		}
+	}
+	return ""
+}
+
+func (r *Repo) releaseById(id string) *Release {
+	for _, rel := range r.Releases {
+		if strconv.FormatInt(rel.Id, 10) == id {
+			return rel
+		}
+	}
+	return nil
+}
+
+func (rel *Release) toGraphQL(skipDescription bool) map[string]any {
+	out := map[string]any{
+		"name":         rel.Name,
+		"tagName":      rel.Tag,
+		"createdAt":    rel.CreatedAt,
+		"publishedAt":  nil,
+		"isDraft":      rel.Draft,
+		"isPrerelease": rel.Prerelease,
+		"description":  rel.Body,
+		"databaseId":   rel.Id,
+		"isLatest":     rel.Latest,
+	}
+	if !rel.PublishedAt.IsZero() {
+		out["publishedAt"] = rel.PublishedAt
+	}
+	if skipDescription {
+		delete(out, "description")
+	}
+	return out
+}
+
+func (rel *Release) toREST() *gogithub.RepositoryRelease {
+	out := &gogithub.RepositoryRelease{
+		ID:         rel.Id,
+		Name:       gogithub.Ptr(rel.Name),
+		TagName:    rel.Tag,
+		Body:       gogithub.Ptr(rel.Body),
+		Draft:      rel.Draft,
+		Prerelease: rel.Prerelease,
+		CreatedAt:  gogithub.Timestamp{Time: rel.CreatedAt},
+	}
+	if !rel.PublishedAt.IsZero() {
+		out.PublishedAt = &gogithub.Timestamp{Time: rel.PublishedAt}
+	}
+	for _, a := range rel.Assets {
+		out.Assets = append(out.Assets, a.toREST())
+	}
+	return out
+}
+
+func (a Asset) toREST() *gogithub.ReleaseAsset {
+	return &gogithub.ReleaseAsset{ID: gogithub.Ptr(a.Id), Name: gogithub.Ptr(a.Name), Size: gogithub.Ptr(len(a.Content))}
+}
+
+// now is the creation date of new releases, it's fixed so outputs are reproducible.
+func now() time.Time {
+	return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeGraphQLError(w http.ResponseWriter, errType string, message string) {
+	writeJSON(w, http.StatusOK, map[string]any{"data": nil, "errors": []map[string]string{{"type": errType, "message": message}}})
+}
+
+func notFound(w http.ResponseWriter) {
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+}
@@ -0,0 +1,323 @@
+// Package gitlab implements github.Forge on top of the GitLab REST API (v4).
+//
+// GitLab has no draft releases: releases with a `released_at` in the future (upcoming releases) are reported as drafts,
+// UpsertRelease creates drafts as upcoming releases and sets `released_at` to now when they are published.
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kumahq/ci-tools/cmd/internal/github"
+)
+
+const DefaultURL = "https://gitlab.com"
+
+// draftReleasedAt is the `released_at` of drafts, far enough in the future for GitLab to report them as upcoming until they are published.
+var draftReleasedAt = time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+var _ github.Forge = &Client{}
+
+// New creates a client for the GitLab instance at baseURL, token is optional for public projects.
+func New(baseURL string, token string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/") + "/api/v4", token: token, httpClient: httpClient}
+}
+
+type release struct {
+	Name        string    `json:"name"`
+	TagName     string    `json:"tag_name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	ReleasedAt  time.Time `json:"released_at"`
+	Upcoming    bool      `json:"upcoming_release"`
+}
+
+func (r release) toRelease() github.Release {
+	out := github.Release{
+		Name:        r.Name,
+		CreatedAt:   r.CreatedAt,
+		IsDraft:     r.Upcoming,
+		Description: r.Description,
+	}
+	if !r.Upcoming {
+		out.PublishedAt = r.ReleasedAt
+	}
+	return out
+}
+
+// Releases lists the releases of the project, the latest is the most recent one that's released.
+func (c *Client) Releases(repo string) ([]github.Release, error) {
+	start := time.Now()
+	var out []github.Release
+	latest := -1
+	for page := "1"; page != ""; {
+		var releases []release
+		res, err := c.do(context.Background(), http.MethodGet, projectPath(repo, "releases")+"?per_page=100&page="+page, nil, &releases)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range releases {
+			out = append(out, r.toRelease())
+			if latest == -1 && !r.Upcoming {
+				latest = len(out) - 1
+			}
+		}
+		page = res.Header.Get("X-Next-Page")
+	}
+	if latest != -1 {
+		out[latest].IsLatest = true
+	}
+	slog.Info("fetched releases", "repo", repo, "releases", len(out), "duration", time.Since(start))
+	return out, nil
+}
+
+func (c *Client) FindRelease(repo, releaseName, tagName string) (*github.Release, error) {
+	releases, err := c.Releases(repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range releases {
+		if r.Name == releaseName || r.Name == tagName {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+type commit struct {
+	Id            string    `json:"id"`
+	Message       string    `json:"message"`
+	CommittedDate time.Time `json:"committed_date"`
+}
+
+type mergeRequest struct {
+	Iid             int    `json:"iid"`
+	Title           string `json:"title"`
+	Description     string `json:"description"`
+	Sha             string `json:"sha"`
+	MergeCommitSha  string `json:"merge_commit_sha"`
+	SquashCommitSha string `json:"squash_commit_sha"`
+	Author          struct {
+		Username string `json:"username"`
+	} `json:"author"`
+}
+
+// clockSkew is the margin taken on commit dates when looking for the merge requests that created them.
+const clockSkew = time.Hour
+
+// History lists the commits of branch, the pull request of a commit is the merged merge request that created it
+// (as merge or squash commit, or as head commit for fast-forward merges).
+// Merge requests are listed in pages of merged merge requests into branch updated since the oldest commit, not looked up for each commit.
+func (c *Client) History(repo, branch, until string) ([]github.Commit, error) {
+	start := time.Now()
+	var out []github.Commit
+	defer func() {
+		slog.Info("fetched history", "repo", repo, "branch", branch, "commits", len(out), "duration", time.Since(start))
+	}()
+	var commits []commit
+	err := func() error {
+		for page := "1"; page != ""; {
+			var res []commit
+			r, err := c.do(context.Background(), http.MethodGet, projectPath(repo, "repository/commits")+"?per_page=100&ref_name="+url.QueryEscape(branch)+"&page="+page, nil, &res)
+			if err != nil {
+				return err
+			}
+			for _, cm := range res {
+				if until != "" && strings.HasPrefix(cm.Id, until) {
+					return nil
+				}
+				commits = append(commits, cm)
+			}
+			page = r.Header.Get("X-Next-Page")
+		}
+		return nil
+	}()
+	if err != nil || len(commits) == 0 {
+		return nil, err
+	}
+	mrs, err := c.mergeRequests(repo, branch, commits)
+	if err != nil {
+		return nil, err
+	}
+	for _, cm := range commits {
+		out = append(out, github.Commit{Sha: cm.Id, Message: cm.Message, PullRequest: mrs[cm.Id]})
+	}
+	return out, nil
+}
+
+// mergeRequests returns the merged merge requests into branch by the commit that they created.
+func (c *Client) mergeRequests(repo, branch string, commits []commit) (map[string]*github.PullRequest, error) {
+	wanted := map[string]bool{}
+	oldest := commits[0].CommittedDate
+	for _, cm := range commits {
+		wanted[cm.Id] = true
+		if cm.CommittedDate.Before(oldest) {
+			oldest = cm.CommittedDate
+		}
+	}
+	out := map[string]*github.PullRequest{}
+	q := url.Values{
+		"state":         {"merged"},
+		"target_branch": {branch},
+		"updated_after": {oldest.Add(-clockSkew).UTC().Format(time.RFC3339)},
+		"per_page":      {"100"},
+	}
+	for page := "1"; page != "" && len(out) < len(wanted); {
+		q.Set("page", page)
+		var mrs []mergeRequest
+		res, err := c.do(context.Background(), http.MethodGet, projectPath(repo, "merge_requests")+"?"+q.Encode(), nil, &mrs)
+		if err != nil {
+			return nil, err
+		}
+		for _, mr := range mrs {
+			pr := &github.PullRequest{Number: mr.Iid, Title: mr.Title, Body: mr.Description, Author: mr.Author.Username}
+			// A merge request is the pull request of a single commit: its merge or squash commit, its head commit for fast-forward merges
+			for _, sha := range []string{mr.MergeCommitSha, mr.SquashCommitSha, mr.Sha} {
+				if sha != "" && wanted[sha] && out[sha] == nil {
+					out[sha] = pr
+					break
+				}
+			}
+		}
+		page = res.Header.Get("X-Next-Page")
+	}
+	return out, nil
+}
+
+func (c *Client) CommitByRef(repo, tag string) (string, error) {
+	var out struct {
+		Commit commit `json:"commit"`
+	}
+	_, err := c.do(context.Background(), http.MethodGet, projectPath(repo, "repository/tags/"+url.PathEscape(tag)), nil, &out)
+	if errors.Is(err, github.ErrNotFound) {
+		return "", nil
+	}
+	return out.Commit.Id, err
+}
+
+func (c *Client) MergeBase(ctx context.Context, repo, base, head string) (string, error) {
+	var out commit
+	q := url.Values{"refs[]": []string{base, head}}
+	_, err := c.do(ctx, http.MethodGet, projectPath(repo, "repository/merge_base")+"?"+q.Encode(), nil, &out)
+	return out.Id, err
+}
+
+func (c *Client) Compare(ctx context.Context, repo, base, head string) (github.Comparison, error) {
+	mergeBase, err := c.MergeBase(ctx, repo, base, head)
+	if err != nil {
+		return github.Comparison{}, err
+	}
+	var out struct {
+		Commits []commit `json:"commits"`
+	}
+	q := url.Values{"from": {mergeBase}, "to": {head}, "straight": {"true"}}
+	if _, err := c.do(ctx, http.MethodGet, projectPath(repo, "repository/compare")+"?"+q.Encode(), nil, &out); err != nil {
+		return github.Comparison{}, err
+	}
+	return github.Comparison{MergeBase: mergeBase, AheadBy: len(out.Commits)}, nil
+}
+
+func (c *Client) UpsertRelease(ctx context.Context, repo, releaseName, tagName string, contentModifier func(*github.ReleaseContent) error) error {
+	var existing release
+	_, err := c.do(ctx, http.MethodGet, projectPath(repo, "releases/"+url.PathEscape(tagName)), nil, &existing)
+	if errors.Is(err, github.ErrNotFound) {
+		content := &github.ReleaseContent{Name: releaseName, Tag: tagName, Draft: true}
+		if err := contentModifier(content); err != nil {
+			return err
+		}
+		payload := map[string]any{
+			"name":        content.Name,
+			"tag_name":    content.Tag,
+			"description": content.Body,
+		}
+		if content.Draft {
+			payload["released_at"] = draftReleasedAt
+		}
+		_, err := c.do(ctx, http.MethodPost, projectPath(repo, "releases"), payload, nil)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	content := &github.ReleaseContent{Name: existing.Name, Tag: existing.TagName, Body: existing.Description, Draft: existing.Upcoming}
+	if err := contentModifier(content); err != nil {
+		return err
+	}
+	if content.Tag != existing.TagName {
+		return fmt.Errorf("can't change the tag of release %s from %s to %s, GitLab releases are identified by their tag", existing.Name, existing.TagName, content.Tag)
+	}
+	payload := map[string]any{
+		"name":        content.Name,
+		"description": content.Body,
+	}
+	switch {
+	case content.Draft && !existing.Upcoming:
+		payload["released_at"] = draftReleasedAt
+	case !content.Draft && existing.Upcoming:
+		payload["released_at"] = time.Now().UTC()
+	}
+	_, err = c.do(ctx, http.MethodPut, projectPath(repo, "releases/"+url.PathEscape(existing.TagName)), payload, nil)
+	return err
+}
+
+// projectPath returns the path of a project resource, repo is the full path of the project (e.g. `group/subgroup/project`).
+func projectPath(repo string, resource string) string {
+	return fmt.Sprintf("/projects/%s/%s", url.PathEscape(repo), resource)
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, body any, out any) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	start := time.Now()
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	slog.Debug("gitlab request", "method", method, "path", path, "status", res.StatusCode, "duration", time.Since(start))
+	if err := github.CheckResponse(res); err != nil {
+		return res, err
+	}
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			return res, fmt.Errorf("failed to decode %s %s: %w", method, path, err)
+		}
+	}
+	return res, nil
+}
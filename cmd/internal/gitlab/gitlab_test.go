@@ -0,0 +1,249 @@
+package gitlab_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kumahq/ci-tools/cmd/internal/github"
+	"github.com/kumahq/ci-tools/cmd/internal/gitlab"
+)
+
+func newServer(t *testing.T, handlers map[string]http.HandlerFunc) *gitlab.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		h, ok := handlers[r.Method+" "+r.URL.EscapedPath()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		h(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return gitlab.New(srv.URL, "token", srv.Client())
+}
+
+func reply(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}
+}
+
+func TestReleases(t *testing.T) {
+	client := newServer(t, map[string]http.HandlerFunc{
+		"GET /api/v4/projects/kumahq%2Fkuma/releases": func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("page") == "1" {
+				w.Header().Set("X-Next-Page", "2")
+				_, _ = io.WriteString(w, `[{"name":"2.12.0","tag_name":"2.12.0","upcoming_release":true,"created_at":"2025-09-01T00:00:00Z","released_at":"2025-10-01T00:00:00Z"}]`)
+				return
+			}
+			_, _ = io.WriteString(w, `[{"name":"2.11.0","tag_name":"2.11.0","description":"## Changelog","created_at":"2025-06-20T00:00:00Z","released_at":"2025-06-21T00:00:00Z"}]`)
+		},
+	})
+	releases, err := client.Releases("kumahq/kuma")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(releases) != 2 {
+		t.Fatalf("expected 2 releases got %d", len(releases))
+	}
+	if !releases[0].IsDraft || !releases[0].PublishedAt.IsZero() || releases[0].IsLatest {
+		t.Errorf("upcoming release should be a draft: %+v", releases[0])
+	}
+	if releases[1].IsDraft || !releases[1].IsLatest || releases[1].PublishedAt.Format("2006-01-02") != "2025-06-21" || releases[1].Description != "## Changelog" {
+		t.Errorf("unexpected release %+v", releases[1])
+	}
+}
+
+func TestHistory(t *testing.T) {
+	client := newServer(t, map[string]http.HandlerFunc{
+		"GET /api/v4/projects/kumahq%2Fkuma/repository/commits": reply(`[
+			{"id":"c4","message":"Merge branch 'feat'","committed_date":"2025-06-05T00:00:00Z"},
+			{"id":"c3","message":"squashed","committed_date":"2025-06-04T00:00:00Z"},
+			{"id":"c2","message":"direct push","committed_date":"2025-06-03T00:00:00Z"},
+			{"id":"c1","message":"ff","committed_date":"2025-06-02T00:00:00Z"},
+			{"id":"c0","message":"older","committed_date":"2025-06-01T00:00:00Z"}
+		]`),
+		"GET /api/v4/projects/kumahq%2Fkuma/merge_requests": func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			if q.Get("state") != "merged" || q.Get("target_branch") != "release-2.11" || q.Get("updated_after") != "2025-06-01T23:00:00Z" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			if q.Get("page") == "1" {
+				w.Header().Set("X-Next-Page", "2")
+				_, _ = io.WriteString(w, `[{"iid":4,"title":"feat: z","sha":"h4","merge_commit_sha":"c4","author":{"username":"carol"}},{"iid":3,"title":"feat: x","description":"body","sha":"h3","squash_commit_sha":"c3","author":{"username":"alice"}}]`)
+				return
+			}
+			_, _ = io.WriteString(w, `[{"iid":1,"title":"fix: y","sha":"c1","author":{"username":"bob"}}]`)
+		},
+	})
+	commits, err := client.History("kumahq/kuma", "release-2.11", "c0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []github.Commit{
+		{Sha: "c4", Message: "Merge branch 'feat'", PullRequest: &github.PullRequest{Number: 4, Title: "feat: z", Author: "carol"}},
+		{Sha: "c3", Message: "squashed", PullRequest: &github.PullRequest{Number: 3, Title: "feat: x", Body: "body", Author: "alice"}},
+		{Sha: "c2", Message: "direct push"},
+		{Sha: "c1", Message: "ff", PullRequest: &github.PullRequest{Number: 1, Title: "fix: y", Author: "bob"}},
+	}
+	if !reflect.DeepEqual(commits, expected) {
+		t.Errorf("got %+v expected %+v", commits, expected)
+	}
+}
+
+func TestCommitByRef(t *testing.T) {
+	client := newServer(t, map[string]http.HandlerFunc{
+		"GET /api/v4/projects/kumahq%2Fkuma/repository/tags/2.11.0": reply(`{"name":"2.11.0","commit":{"id":"abc"}}`),
+	})
+	sha, err := client.CommitByRef("kumahq/kuma", "2.11.0")
+	if err != nil || sha != "abc" {
+		t.Errorf("expected abc got %q %v", sha, err)
+	}
+	sha, err = client.CommitByRef("kumahq/kuma", "9.9.9")
+	if err != nil || sha != "" {
+		t.Errorf("expected no commit for a missing tag got %q %v", sha, err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	client := newServer(t, map[string]http.HandlerFunc{
+		"GET /api/v4/projects/kumahq%2Fkuma/repository/merge_base": func(w http.ResponseWriter, r *http.Request) {
+			if refs := r.URL.Query()["refs[]"]; !reflect.DeepEqual(refs, []string{"abc", "release-2.11"}) {
+				t.Errorf("unexpected refs %v", refs)
+			}
+			_, _ = io.WriteString(w, `{"id":"abc"}`)
+		},
+		"GET /api/v4/projects/kumahq%2Fkuma/repository/compare": func(w http.ResponseWriter, r *http.Request) {
+			if q := r.URL.Query(); q.Get("from") != "abc" || q.Get("to") != "release-2.11" || q.Get("straight") != "true" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			_, _ = io.WriteString(w, `{"commits":[{"id":"c2"},{"id":"c1"}]}`)
+		},
+	})
+	comparison, err := client.Compare(t.Context(), "kumahq/kuma", "abc", "release-2.11")
+	if err != nil || comparison != (github.Comparison{MergeBase: "abc", AheadBy: 2}) {
+		t.Errorf("unexpected comparison %+v %v", comparison, err)
+	}
+}
+
+func TestUpsertRelease(t *testing.T) {
+	var created, updated map[string]string
+	client := newServer(t, map[string]http.HandlerFunc{
+		"GET /api/v4/projects/kumahq%2Fkuma/releases/2.11.0": reply(`{"name":"2.11.0","tag_name":"2.11.0","description":"intro\n## Changelog\nold","upcoming_release":true}`),
+		"PUT /api/v4/projects/kumahq%2Fkuma/releases/2.11.0": func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&updated)
+			_, _ = io.WriteString(w, `{}`)
+		},
+		"POST /api/v4/projects/kumahq%2Fkuma/releases": func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = io.WriteString(w, `{}`)
+		},
+	})
+	err := client.UpsertRelease(t.Context(), "kumahq/kuma", "2.11.0", "2.11.0", func(release *github.ReleaseContent) error {
+		if !release.Draft || release.Body != "intro\n## Changelog\nold" {
+			t.Errorf("unexpected existing release %+v", release)
+		}
+		release.Body = "new"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(updated, map[string]string{"name": "2.11.0", "description": "new"}) {
+		t.Errorf("unexpected update %v", updated)
+	}
+	err = client.UpsertRelease(t.Context(), "kumahq/kuma", "2.11.1", "2.11.1", func(release *github.ReleaseContent) error {
+		release.Body = "body"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(created, map[string]string{"name": "2.11.1", "tag_name": "2.11.1", "description": "body", "released_at": "2999-01-01T00:00:00Z"}) {
+		t.Errorf("unexpected creation %v", created)
+	}
+}
+
+// TestUpsertReleaseDraftLifecycle creates a draft, updates it on a second run and publishes it.
+func TestUpsertReleaseDraftLifecycle(t *testing.T) {
+	type stored struct {
+		Name        string    `json:"name"`
+		TagName     string    `json:"tag_name"`
+		Description string    `json:"description"`
+		ReleasedAt  time.Time `json:"released_at"`
+	}
+	var rel *stored
+	save := func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if rel == nil {
+			rel = &stored{TagName: req["tag_name"].(string), ReleasedAt: time.Now()}
+		}
+		rel.Name = req["name"].(string)
+		rel.Description = req["description"].(string)
+		if at, ok := req["released_at"].(string); ok {
+			rel.ReleasedAt, _ = time.Parse(time.RFC3339, at)
+		}
+		_, _ = io.WriteString(w, `{}`)
+	}
+	client := newServer(t, map[string]http.HandlerFunc{
+		"GET /api/v4/projects/kumahq%2Fkuma/releases/2.11.0": func(w http.ResponseWriter, r *http.Request) {
+			if rel == nil {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"name": rel.Name, "tag_name": rel.TagName, "description": rel.Description,
+				"released_at": rel.ReleasedAt, "upcoming_release": rel.ReleasedAt.After(time.Now()),
+			})
+		},
+		"POST /api/v4/projects/kumahq%2Fkuma/releases":       save,
+		"PUT /api/v4/projects/kumahq%2Fkuma/releases/2.11.0": save,
+	})
+	upsert := func(body string, publish bool) {
+		t.Helper()
+		err := client.UpsertRelease(t.Context(), "kumahq/kuma", "2.11.0", "2.11.0", func(release *github.ReleaseContent) error {
+			if !release.Draft {
+				t.Errorf("release %s should still be a draft", release.Name)
+			}
+			release.Body = body
+			release.Draft = !publish
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	upsert("first", false)
+	if !rel.ReleasedAt.After(time.Now()) {
+		t.Errorf("release should be created as upcoming, released at %s", rel.ReleasedAt)
+	}
+	upsert("second", false)
+	if rel.Description != "second" || !rel.ReleasedAt.After(time.Now()) {
+		t.Errorf("release should be updated and still upcoming: %+v", rel)
+	}
+	upsert("published", true)
+	if rel.ReleasedAt.After(time.Now()) {
+		t.Errorf("release should be published, released at %s", rel.ReleasedAt)
+	}
+	err := client.UpsertRelease(t.Context(), "kumahq/kuma", "2.11.0", "2.11.0", func(release *github.ReleaseContent) error {
+		if release.Draft {
+			t.Error("published release shouldn't be a draft")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,153 @@
+package helmrepo
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrChartNotFound = errors.New("chart not found in index")
+
+// Index is the subset of a Helm repository `index.yaml` we care about.
+type Index struct {
+	APIVersion string                    `yaml:"apiVersion"`
+	Entries    map[string][]ChartVersion `yaml:"entries"`
+}
+
+type ChartVersion struct {
+	Name       string    `yaml:"name"`
+	Version    string    `yaml:"version"`
+	AppVersion string    `yaml:"appVersion"`
+	URLs       []string  `yaml:"urls"`
+	Digest     string    `yaml:"digest"`
+	Created    time.Time `yaml:"created"`
+}
+
+func ParseIndex(r io.Reader) (Index, error) {
+	var out Index
+	if err := yaml.NewDecoder(r).Decode(&out); err != nil {
+		return out, fmt.Errorf("invalid helm repository index: %w", err)
+	}
+	return out, nil
+}
+
+// Get returns the entry for this chart and version, versions are compared ignoring a `v` prefix.
+func (i Index) Get(name, version string) (ChartVersion, error) {
+	for _, cv := range i.Entries[name] {
+		if strings.TrimPrefix(cv.Version, "v") == strings.TrimPrefix(version, "v") {
+			return cv, nil
+		}
+	}
+	return ChartVersion{}, fmt.Errorf("%w: %s-%s", ErrChartNotFound, name, version)
+}
+
+// ChartURL returns the absolute url of the chart tarball, relative urls are resolved against the index url.
+func (cv ChartVersion) ChartURL(indexURL string) (string, error) {
+	if len(cv.URLs) == 0 {
+		return "", fmt.Errorf("chart %s-%s has no urls", cv.Name, cv.Version)
+	}
+	base, err := url.Parse(indexURL)
+	if err != nil {
+		return "", err
+	}
+	u, err := base.Parse(cv.URLs[0])
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// ImageRef is an image referenced in a chart's default values.
+type ImageRef struct {
+	// Path is the dotted path of the values key holding the image (e.g. `controlPlane.image`)
+	Path       string
+	Registry   string
+	Repository string
+	// Tag is empty when the chart defaults it (usually to its appVersion)
+	Tag string
+}
+
+func (i ImageRef) String() string {
+	repo := i.Repository
+	if i.Registry != "" {
+		repo = i.Registry + "/" + repo
+	}
+	tag := i.Tag
+	if tag == "" {
+		tag = "<appVersion>"
+	}
+	return fmt.Sprintf("%s (%s:%s)", i.Path, repo, tag)
+}
+
+// InspectChart streams a chart tarball once, returning its sha256 digest and the images referenced in its `values.yaml`.
+func InspectChart(r io.Reader, chartName string) (string, []ImageRef, error) {
+	h := sha256.New()
+	gz, err := gzip.NewReader(io.TeeReader(r, h))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid chart archive: %w", err)
+	}
+	var images []ImageRef
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid chart archive: %w", err)
+		}
+		if path.Clean(hdr.Name) != path.Join(chartName, "values.yaml") {
+			continue
+		}
+		var values map[string]any
+		if err := yaml.NewDecoder(tr).Decode(&values); err != nil && err != io.EOF {
+			return "", nil, fmt.Errorf("invalid values.yaml: %w", err)
+		}
+		images = FindImages(values)
+	}
+	// Drain what's left after the tar end marker so the digest covers the whole file
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return "", nil, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), images, nil
+}
+
+// FindImages walks helm values and returns every map that looks like an image (it has a `repository` key).
+func FindImages(values map[string]any) []ImageRef {
+	var out []ImageRef
+	var walk func(prefix string, v map[string]any)
+	walk = func(prefix string, v map[string]any) {
+		if repo, ok := v["repository"].(string); ok {
+			registry, _ := v["registry"].(string)
+			out = append(out, ImageRef{Path: prefix, Registry: registry, Repository: repo, Tag: scalarString(v["tag"])})
+		}
+		for k, child := range v {
+			if m, ok := child.(map[string]any); ok {
+				walk(strings.TrimPrefix(prefix+"."+k, "."), m)
+			}
+		}
+	}
+	walk("", values)
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Path < out[j].Path
+	})
+	return out
+}
+
+func scalarString(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
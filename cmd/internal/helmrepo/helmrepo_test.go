@@ -0,0 +1,110 @@
+package helmrepo_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kumahq/ci-tools/cmd/internal/helmrepo"
+)
+
+const index = `
+apiVersion: v1
+entries:
+  kuma:
+  - name: kuma
+    version: 2.11.8
+    appVersion: 2.11.8
+    digest: abcd
+    urls:
+    - charts/kuma-2.11.8.tgz
+  - name: kuma
+    version: 2.11.7
+    appVersion: 2.11.7
+    urls:
+    - https://example.com/kuma-2.11.7.tgz
+`
+
+func TestIndex(t *testing.T) {
+	idx, err := helmrepo.ParseIndex(strings.NewReader(index))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := idx.Get("kuma", "v2.11.8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.AppVersion != "2.11.8" || entry.Digest != "abcd" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	u, err := entry.ChartURL("https://kumahq.github.io/charts/index.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != "https://kumahq.github.io/charts/charts/kuma-2.11.8.tgz" {
+		t.Errorf("unexpected url %s", u)
+	}
+	entry, _ = idx.Get("kuma", "2.11.7")
+	if u, _ := entry.ChartURL("https://kumahq.github.io/charts/index.yaml"); u != "https://example.com/kuma-2.11.7.tgz" {
+		t.Errorf("unexpected url %s", u)
+	}
+	if _, err := idx.Get("kuma", "2.11.9"); !errors.Is(err, helmrepo.ErrChartNotFound) {
+		t.Errorf("expected not found got %v", err)
+	}
+}
+
+func TestInspectChart(t *testing.T) {
+	values := `
+global:
+  image:
+    registry: docker.io/kumahq
+    tag: ""
+controlPlane:
+  image:
+    repository: kuma-cp
+dataPlane:
+  image:
+    repository: kuma-dp
+    tag: 2.11.8
+  initImage:
+    registry: ghcr.io/kumahq
+    repository: kuma-init
+    tag: ""
+`
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{"kuma/Chart.yaml": "name: kuma\n", "kuma/values.yaml": values} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+	expectedDigest := sha256.Sum256(buf.Bytes())
+
+	digest, images, err := helmrepo.InspectChart(bytes.NewReader(buf.Bytes()), "kuma")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != hex.EncodeToString(expectedDigest[:]) {
+		t.Errorf("unexpected digest %s", digest)
+	}
+	expected := []helmrepo.ImageRef{
+		{Path: "controlPlane.image", Repository: "kuma-cp"},
+		{Path: "dataPlane.image", Repository: "kuma-dp", Tag: "2.11.8"},
+		{Path: "dataPlane.initImage", Registry: "ghcr.io/kumahq", Repository: "kuma-init"},
+	}
+	if !reflect.DeepEqual(images, expected) {
+		t.Errorf("got:\n%#v\nexpected:\n%#v", images, expected)
+	}
+}
@@ -0,0 +1,213 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const DockerHub = "registry-1.docker.io"
+
+var ErrNotFound = errors.New("not found in registry")
+
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Client is a minimal anonymous OCI distribution client, just enough to resolve tags and read cosign signatures.
+type Client struct {
+	host       string
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+func New(host string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{host: host, httpClient: httpClient, tokens: map[string]string{}}
+}
+
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type Manifest struct {
+	MediaType string       `json:"mediaType"`
+	Config    Descriptor   `json:"config"`
+	Layers    []Descriptor `json:"layers"`
+}
+
+// CosignSignatureTag returns the tag cosign uses to store the signature of an image with this digest.
+func CosignSignatureTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ".sig"
+}
+
+// Resolve returns the digest of the manifest referenced by tag.
+func (c *Client) Resolve(ctx context.Context, repo, tag string) (string, error) {
+	res, err := c.do(ctx, http.MethodHead, repo, fmt.Sprintf("/v2/%s/manifests/%s", repo, tag), manifestMediaTypes)
+	if err != nil {
+		return "", err
+	}
+	_ = res.Body.Close()
+	digest := res.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("no digest returned for %s:%s", repo, tag)
+	}
+	return digest, nil
+}
+
+// Manifest fetches an image manifest by tag or digest.
+func (c *Client) Manifest(ctx context.Context, repo, ref string) (Manifest, error) {
+	var out Manifest
+	res, err := c.do(ctx, http.MethodGet, repo, fmt.Sprintf("/v2/%s/manifests/%s", repo, ref), manifestMediaTypes)
+	if err != nil {
+		return out, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	err = json.NewDecoder(res.Body).Decode(&out)
+	return out, err
+}
+
+// Blob fetches a blob by digest, it's meant for small blobs like signature payloads.
+func (c *Client) Blob(ctx context.Context, repo, digest string) ([]byte, error) {
+	res, err := c.do(ctx, http.MethodGet, repo, fmt.Sprintf("/v2/%s/blobs/%s", repo, digest), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	return io.ReadAll(io.LimitReader(res.Body, 1024*1024))
+}
+
+func (c *Client) do(ctx context.Context, method, repo, path string, accept []string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("https://%s%s", c.host, path), nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range accept {
+			req.Header.Add("Accept", a)
+		}
+		c.mu.Lock()
+		token := c.tokens[repo]
+		c.mu.Unlock()
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case res.StatusCode == http.StatusOK:
+			return res, nil
+		case res.StatusCode == http.StatusUnauthorized && attempt == 0:
+			_ = res.Body.Close()
+			if err := c.authenticate(ctx, repo, res.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, err
+			}
+			continue
+		case res.StatusCode == http.StatusNotFound:
+			_ = res.Body.Close()
+			return nil, fmt.Errorf("%w: %s%s", ErrNotFound, c.host, path)
+		default:
+			_ = res.Body.Close()
+			return nil, fmt.Errorf("got status: %d for %s%s", res.StatusCode, c.host, path)
+		}
+	}
+}
+
+// authenticate gets an anonymous pull token following the registry token auth spec.
+func (c *Client) authenticate(ctx context.Context, repo, challenge string) error {
+	params := parseChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return fmt.Errorf("registry %s requires unsupported authentication %q", c.host, challenge)
+	}
+	q := url.Values{}
+	if s := params["service"]; s != "" {
+		q.Set("service", s)
+	}
+	q.Set("scope", fmt.Sprintf("repository:%s:pull", repo))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get registry token from %s status: %d", realm, res.StatusCode)
+	}
+	var tok struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&tok); err != nil {
+		return err
+	}
+	if tok.Token == "" {
+		tok.Token = tok.AccessToken
+	}
+	c.mu.Lock()
+	c.tokens[repo] = tok.Token
+	c.mu.Unlock()
+	return nil
+}
+
+// parseChallenge parses `Bearer realm="...",service="...",scope="..."`, quoted values can contain commas and escaped quotes.
+func parseChallenge(challenge string) map[string]string {
+	out := map[string]string{}
+	scheme, rest, found := strings.Cut(strings.TrimSpace(challenge), " ")
+	if !found || !strings.EqualFold(scheme, "bearer") {
+		return out
+	}
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " \t,")
+		k, v, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		k = strings.ToLower(strings.TrimSpace(k))
+		v = strings.TrimLeft(v, " \t")
+		if strings.HasPrefix(v, `"`) {
+			var value strings.Builder
+			i := 1
+			for ; i < len(v) && v[i] != '"'; i++ {
+				if v[i] == '\\' && i+1 < len(v) {
+					i++
+				}
+				value.WriteByte(v[i])
+			}
+			out[k] = value.String()
+			rest = v[min(i+1, len(v)):]
+			continue
+		}
+		value, next, _ := strings.Cut(v, ",")
+		out[k] = strings.TrimSpace(value)
+		rest = next
+	}
+	return out
+}
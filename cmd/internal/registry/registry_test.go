@@ -0,0 +1,104 @@
+package registry
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseChallenge(t *testing.T) {
+	for _, c := range []struct {
+		desc      string
+		challenge string
+		expected  map[string]string
+	}{
+		{
+			desc:      "docker hub",
+			challenge: `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`,
+			expected:  map[string]string{"realm": "https://auth.docker.io/token", "service": "registry.docker.io"},
+		},
+		{
+			desc:      "comma in a quoted value",
+			challenge: `Bearer realm="https://ghcr.io/token",scope="repository:kumahq/kuma:pull,push",service="ghcr.io"`,
+			expected:  map[string]string{"realm": "https://ghcr.io/token", "scope": "repository:kumahq/kuma:pull,push", "service": "ghcr.io"},
+		},
+		{
+			desc:      "spaces, unquoted and escaped values",
+			challenge: `bearer  Realm="https://example.com/token", service=example.com, error="say \"hi\""`,
+			expected:  map[string]string{"realm": "https://example.com/token", "service": "example.com", "error": `say "hi"`},
+		},
+		{
+			desc:      "unterminated quote",
+			challenge: `Bearer realm="https://example.com/token`,
+			expected:  map[string]string{"realm": "https://example.com/token"},
+		},
+		{
+			desc:      "basic auth",
+			challenge: `Basic realm="registry"`,
+			expected:  map[string]string{},
+		},
+	} {
+		t.Run(c.desc, func(t *testing.T) {
+			if res := parseChallenge(c.challenge); !reflect.DeepEqual(res, c.expected) {
+				t.Errorf("got %v expected %v", res, c.expected)
+			}
+		})
+	}
+}
+
+func TestTokenFlow(t *testing.T) {
+	var tokenRequests int
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			tokenRequests++
+			if q := r.URL.Query(); q.Get("service") != "example.com" || q.Get("scope") != "repository:kumahq/kuma:pull" {
+				t.Errorf("unexpected token query %s", r.URL.RawQuery)
+			}
+			_, _ = io.WriteString(w, `{"access_token":"secret"}`)
+		case r.Header.Get("Authorization") != "Bearer secret":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+srv.URL+`/token",scope="repository:kumahq/kuma:pull,push",service="example.com"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/kumahq/kuma/manifests/2.11.0":
+			w.Header().Set("Docker-Content-Digest", "sha256:abcd")
+		case r.URL.Path == "/v2/kumahq/kuma/blobs/sha256:abcd":
+			_, _ = io.WriteString(w, "payload")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	client := New(strings.TrimPrefix(srv.URL, "https://"), srv.Client())
+
+	digest, err := client.Resolve(t.Context(), "kumahq/kuma", "2.11.0")
+	if err != nil || digest != "sha256:abcd" {
+		t.Fatalf("expected sha256:abcd got %q %v", digest, err)
+	}
+	blob, err := client.Blob(t.Context(), "kumahq/kuma", digest)
+	if err != nil || string(blob) != "payload" {
+		t.Errorf("expected the payload got %q %v", blob, err)
+	}
+	if tokenRequests != 1 {
+		t.Errorf("expected the token to be reused got %d token requests", tokenRequests)
+	}
+	if _, err := client.Resolve(t.Context(), "kumahq/kuma", "9.9.9"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found got %v", err)
+	}
+}
+
+func TestUnsupportedChallenge(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	client := New(strings.TrimPrefix(srv.URL, "https://"), srv.Client())
+	if _, err := client.Resolve(t.Context(), "kumahq/kuma", "2.11.0"); err == nil || !strings.Contains(err.Error(), "unsupported authentication") {
+		t.Errorf("expected an unsupported authentication error got %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package renovate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// node is a json5 value with its position in the source.
+type node struct {
+	// kind is '{', '[', 's' for strings and 'l' for other literals (numbers, booleans, null)
+	kind       byte
+	start, end int
+	str        string
+	members    []member
+	items      []node
+}
+
+type member struct {
+	key      string
+	keyStart int
+	value    node
+}
+
+func (n node) member(key string) *member {
+	for i := range n.members {
+		if n.members[i].key == key {
+			return &n.members[i]
+		}
+	}
+	return nil
+}
+
+// parse parses a json5 document (json with comments, trailing commas, single quoted strings and unquoted keys).
+func parse(src string) (node, error) {
+	p := &parser{src: src}
+	n, err := p.value()
+	if err != nil {
+		return n, err
+	}
+	if p.pos = skipSpace(src, p.pos); p.pos != len(src) {
+		return n, p.errorf("unexpected content after the end of the document")
+	}
+	return n, nil
+}
+
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) errorf(format string, a ...any) error {
+	line := strings.Count(p.src[:min(p.pos, len(p.src))], "\n") + 1
+	return fmt.Errorf("invalid json5 at line %d: %s", line, fmt.Sprintf(format, a...))
+}
+
+// skipSpace returns the position of the first character after pos that isn't whitespace or in a comment.
+func skipSpace(src string, pos int) int {
+	for pos < len(src) {
+		switch {
+		case strings.ContainsRune(" \t\r\n", rune(src[pos])):
+			pos++
+		case strings.HasPrefix(src[pos:], "\ufeff"):
+			// byte order mark
+			pos += len("\ufeff")
+		case strings.HasPrefix(src[pos:], "//"):
+			end := strings.IndexByte(src[pos:], '\n')
+			if end == -1 {
+				return len(src)
+			}
+			pos += end + 1
+		case strings.HasPrefix(src[pos:], "/*"):
+			end := strings.Index(src[pos+2:], "*/")
+			if end == -1 {
+				return len(src)
+			}
+			pos += end + 4
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+func (p *parser) value() (node, error) {
+	p.pos = skipSpace(p.src, p.pos)
+	if p.pos >= len(p.src) {
+		return node{}, p.errorf("unexpected end of document")
+	}
+	start := p.pos
+	switch c := p.src[p.pos]; c {
+	case '{':
+		n := node{kind: '{', start: start}
+		p.pos++
+		for {
+			p.pos = skipSpace(p.src, p.pos)
+			if p.pos < len(p.src) && p.src[p.pos] == '}' {
+				p.pos++
+				n.end = p.pos
+				return n, nil
+			}
+			keyStart := p.pos
+			key, err := p.key()
+			if err != nil {
+				return n, err
+			}
+			if p.pos = skipSpace(p.src, p.pos); p.pos >= len(p.src) || p.src[p.pos] != ':' {
+				return n, p.errorf("expected ':' after key %q", key)
+			}
+			p.pos++
+			v, err := p.value()
+			if err != nil {
+				return n, err
+			}
+			n.members = append(n.members, member{key: key, keyStart: keyStart, value: v})
+			if err := p.separator('}'); err != nil {
+				return n, err
+			}
+		}
+	case '[':
+		n := node{kind: '[', start: start}
+		p.pos++
+		for {
+			p.pos = skipSpace(p.src, p.pos)
+			if p.pos < len(p.src) && p.src[p.pos] == ']' {
+				p.pos++
+				n.end = p.pos
+				return n, nil
+			}
+			v, err := p.value()
+			if err != nil {
+				return n, err
+			}
+			n.items = append(n.items, v)
+			if err := p.separator(']'); err != nil {
+				return n, err
+			}
+		}
+	case '"', '\'':
+		s, err := p.string()
+		return node{kind: 's', start: start, end: p.pos, str: s}, err
+	default:
+		for p.pos < len(p.src) && !strings.ContainsRune(" \t\r\n,:[]{}/\"'", rune(p.src[p.pos])) {
+			p.pos++
+		}
+		if p.pos == start {
+			return node{}, p.errorf("unexpected character %q", c)
+		}
+		return node{kind: 'l', start: start, end: p.pos, str: p.src[start:p.pos]}, nil
+	}
+}
+
+// separator consumes the ',' after a member or an item, it leaves the closing character to the caller.
+func (p *parser) separator(closing byte) error {
+	p.pos = skipSpace(p.src, p.pos)
+	switch {
+	case p.pos >= len(p.src):
+		return p.errorf("unexpected end of document, expected %q", closing)
+	case p.src[p.pos] == ',':
+		p.pos++
+	case p.src[p.pos] != closing:
+		return p.errorf("expected ',' or %q", closing)
+	}
+	return nil
+}
+
+func (p *parser) key() (string, error) {
+	if c := p.src[p.pos]; c == '"' || c == '\'' {
+		return p.string()
+	}
+	start := p.pos
+	for p.pos < len(p.src) && (isIdentifierChar(p.src[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected a key")
+	}
+	return p.src[start:p.pos], nil
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// string parses a double or single quoted string.
+func (p *parser) string() (string, error) {
+	quote := p.src[p.pos]
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) && p.src[p.pos] != quote {
+		if p.src[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		return "", p.errorf("unterminated string")
+	}
+	p.pos++
+	raw := p.src[start+1 : p.pos-1]
+	if quote == '\'' {
+		// turn it into a double quoted string to unquote it
+		raw = strings.ReplaceAll(strings.ReplaceAll(raw, `\'`, `'`), `"`, `\"`)
+	}
+	s, err := strconv.Unquote(`"` + raw + `"`)
+	if err != nil {
+		return "", p.errorf("invalid string %s", p.src[start:p.pos])
+	}
+	return s, nil
+}
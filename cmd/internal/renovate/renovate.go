@@ -0,0 +1,225 @@
+// Package renovate updates the base branches of a renovate config (json or json5) in place.
+//
+// Only the spans of the values that change are rewritten so comments, formatting and all other settings of the file are preserved.
+package renovate
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// BaseBranchPatternsKey is the setting listing the branches renovate updates.
+	BaseBranchPatternsKey = "baseBranchPatterns"
+	// legacyBaseBranchesKey is the name of BaseBranchPatternsKey before renovate 41, it's updated if it's the one in the file.
+	legacyBaseBranchesKey = "baseBranches"
+	packageRulesKey       = "packageRules"
+	// ManagedRulePrefix starts the description of the package rules generated by Update, they are replaced on each update.
+	ManagedRulePrefix = "Managed by release-tool:"
+)
+
+// SecurityOnlyBranch is a branch that should only get security updates (e.g. it's close to its end of life).
+type SecurityOnlyBranch struct {
+	Branch string
+	Reason string
+}
+
+// Changes is what Update changed.
+type Changes struct {
+	Added        []string `json:"added"`
+	Removed      []string `json:"removed"`
+	SecurityOnly []string `json:"securityOnly"`
+}
+
+// Update sets the base branches of the renovate config in content to branches and replaces the package rules it manages
+// with one disabling non security updates for each of securityOnly.
+func Update(content []byte, branches []string, securityOnly []SecurityOnlyBranch) ([]byte, Changes, error) {
+	changes := Changes{Added: []string{}, Removed: []string{}, SecurityOnly: []string{}}
+	src := string(content)
+	root, err := parse(src)
+	if err != nil {
+		return nil, changes, err
+	}
+	if root.kind != '{' {
+		return nil, changes, fmt.Errorf("renovate config must be an object")
+	}
+	unit := root.memberIndent(src)
+
+	var edits []edit
+	key := BaseBranchPatternsKey
+	if root.member(BaseBranchPatternsKey) == nil && root.member(legacyBaseBranchesKey) != nil {
+		key = legacyBaseBranchesKey
+	}
+	var previous []string
+	if m := root.member(key); m != nil {
+		if m.value.kind != '[' {
+			return nil, changes, fmt.Errorf("%s must be an array", key)
+		}
+		for _, item := range m.value.items {
+			previous = append(previous, item.str)
+		}
+		if !slices.Equal(previous, branches) {
+			edits = append(edits, edit{start: m.value.start, end: m.value.end, text: stringArray(branches, !strings.Contains(src[m.value.start:m.value.end], "\n"), lineIndent(src, m.keyStart), unit)})
+		}
+	} else {
+		edits = append(edits, root.addMember(src, key, stringArray(branches, true, unit, unit), unit))
+	}
+	for _, b := range branches {
+		if !slices.Contains(previous, b) {
+			changes.Added = append(changes.Added, b)
+		}
+	}
+	for _, b := range previous {
+		if !slices.Contains(branches, b) {
+			changes.Removed = append(changes.Removed, b)
+		}
+	}
+
+	var rules []string
+	for _, s := range securityOnly {
+		changes.SecurityOnly = append(changes.SecurityOnly, s.Branch)
+		rules = append(rules, securityOnlyRule(s))
+	}
+	if m := root.member(packageRulesKey); m != nil {
+		if m.value.kind != '[' {
+			return nil, changes, fmt.Errorf("%s must be an array", packageRulesKey)
+		}
+		if e, ok := replaceManagedRules(src, m, rules, unit); ok {
+			edits = append(edits, e)
+		}
+	} else if len(rules) > 0 {
+		indent := unit + unit
+		var items []string
+		for _, r := range rules {
+			items = append(items, "\n"+indent+indentRule(r, indent))
+		}
+		edits = append(edits, root.addMember(src, packageRulesKey, "["+strings.Join(items, ",")+"\n"+unit+"]", unit))
+	}
+
+	slices.SortFunc(edits, func(a, b edit) int { return b.start - a.start })
+	for _, e := range edits {
+		src = src[:e.start] + e.text + src[e.end:]
+	}
+	return []byte(src), changes, nil
+}
+
+type edit struct {
+	start, end int
+	text       string
+}
+
+// stringArray renders a json array of strings inline or with an item per line.
+func stringArray(items []string, inline bool, indent string, unit string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		b, _ := json.Marshal(item)
+		quoted[i] = string(b)
+	}
+	if inline || len(items) == 0 {
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+	return "[\n" + indent + unit + strings.Join(quoted, ",\n"+indent+unit) + "\n" + indent + "]"
+}
+
+func securityOnlyRule(s SecurityOnlyBranch) string {
+	rule := struct {
+		Description       string   `json:"description"`
+		MatchBaseBranches []string `json:"matchBaseBranches"`
+		MatchPackageNames []string `json:"matchPackageNames"`
+		Enabled           bool     `json:"enabled"`
+	}{
+		Description:       fmt.Sprintf("%s only security updates on %s (%s)", ManagedRulePrefix, s.Branch, s.Reason),
+		MatchBaseBranches: []string{s.Branch},
+		MatchPackageNames: []string{"*"},
+	}
+	b, _ := json.MarshalIndent(rule, "", "  ")
+	return string(b)
+}
+
+// indentRule indents all lines but the first of a rendered rule.
+func indentRule(rule string, indent string) string {
+	return strings.ReplaceAll(rule, "\n", "\n"+indent)
+}
+
+// replaceManagedRules drops the rules generated by a previous update and appends rules, it keeps the text of all other rules as is.
+func replaceManagedRules(src string, m *member, rules []string, unit string) (edit, bool) {
+	arr := m.value
+	var kept []string
+	managed := 0
+	// each element keeps the text since the previous separator (whitespace and comments included)
+	lead := arr.start + 1
+	for _, item := range arr.items {
+		text := src[lead:item.end]
+		lead = item.end
+		if rest := skipSpace(src, lead); rest < len(src) && src[rest] == ',' {
+			lead = rest + 1
+		}
+		if isManagedRule(item) {
+			managed++
+			continue
+		}
+		kept = append(kept, text)
+	}
+	if managed == 0 && len(rules) == 0 {
+		return edit{}, false
+	}
+	outer := lineIndent(src, m.keyStart)
+	indent := outer + unit
+	for _, r := range rules {
+		kept = append(kept, "\n"+indent+indentRule(r, indent))
+	}
+	if len(kept) == 0 {
+		return edit{start: arr.start, end: arr.end, text: "[]"}, true
+	}
+	// keep the whitespace and comments before the closing bracket
+	tail := src[lead : arr.end-1]
+	if !strings.Contains(tail, "\n") {
+		tail = "\n" + outer
+	}
+	return edit{start: arr.start, end: arr.end, text: "[" + strings.Join(kept, ",") + tail + "]"}, true
+}
+
+func isManagedRule(n node) bool {
+	if n.kind != '{' {
+		return false
+	}
+	d := n.member("description")
+	return d != nil && d.value.kind == 's' && strings.HasPrefix(d.value.str, ManagedRulePrefix)
+}
+
+// lineIndent returns the whitespace between the start of the line and pos.
+func lineIndent(src string, pos int) string {
+	start := strings.LastIndex(src[:pos], "\n") + 1
+	indent := src[start:pos]
+	if strings.TrimLeft(indent, " \t") != "" {
+		return ""
+	}
+	return indent
+}
+
+// memberIndent returns the indentation of the members of the object, 2 spaces if it can't be found.
+func (n node) memberIndent(src string) string {
+	for _, m := range n.members {
+		if indent := lineIndent(src, m.keyStart); indent != "" {
+			return indent
+		}
+	}
+	return "  "
+}
+
+// addMember returns the edit adding `"key": value` as last member of the object n.
+func (n node) addMember(src string, key string, value string, unit string) edit {
+	k, _ := json.Marshal(key)
+	text := "\n" + unit + string(k) + ": " + value
+	if len(n.members) == 0 {
+		return edit{start: n.start, end: n.end, text: "{" + text + "\n}"}
+	}
+	last := n.members[len(n.members)-1].value.end
+	if next := skipSpace(src, last); src[next] == ',' {
+		// json5 trailing comma
+		return edit{start: next + 1, end: next + 1, text: text + ","}
+	}
+	return edit{start: last, end: last, text: "," + text}
+}
@@ -0,0 +1,218 @@
+package renovate_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kumahq/ci-tools/cmd/internal/renovate"
+)
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		desc         string
+		content      string
+		branches     []string
+		securityOnly []renovate.SecurityOnlyBranch
+		expected     string
+		changes      renovate.Changes
+	}{
+		{
+			desc: "replaces the base branches",
+			content: `{
+  "extends": ["config:recommended"],
+  "baseBranchPatterns": ["master", "release-2.9", "release-2.10"],
+  "labels": ["dependencies"]
+}
+`,
+			branches: []string{"master", "release-2.10", "release-2.11"},
+			expected: `{
+  "extends": ["config:recommended"],
+  "baseBranchPatterns": ["master", "release-2.10", "release-2.11"],
+  "labels": ["dependencies"]
+}
+`,
+			changes: renovate.Changes{Added: []string{"release-2.11"}, Removed: []string{"release-2.9"}, SecurityOnly: []string{}},
+		},
+		{
+			desc: "keeps a multiline array multiline",
+			content: `{
+    "baseBranchPatterns": [
+        "master",
+        "release-2.9"
+    ]
+}`,
+			branches: []string{"master", "release-2.10"},
+			expected: `{
+    "baseBranchPatterns": [
+        "master",
+        "release-2.10"
+    ]
+}`,
+			changes: renovate.Changes{Added: []string{"release-2.10"}, Removed: []string{"release-2.9"}, SecurityOnly: []string{}},
+		},
+		{
+			desc: "updates the legacy key",
+			content: `{
+  "baseBranches": ["master"]
+}`,
+			branches: []string{"master", "release-2.10"},
+			expected: `{
+  "baseBranches": ["master", "release-2.10"]
+}`,
+			changes: renovate.Changes{Added: []string{"release-2.10"}, Removed: []string{}, SecurityOnly: []string{}},
+		},
+		{
+			desc: "adds the base branches when missing",
+			content: `{
+  "extends": ["config:recommended"]
+}
+`,
+			branches: []string{"master", "release-2.10"},
+			expected: `{
+  "extends": ["config:recommended"],
+  "baseBranchPatterns": ["master", "release-2.10"]
+}
+`,
+			changes: renovate.Changes{Added: []string{"master", "release-2.10"}, Removed: []string{}, SecurityOnly: []string{}},
+		},
+		{
+			desc: "preserves json5 comments, unquoted keys and trailing commas",
+			content: `// renovate config
+{
+  extends: ['config:recommended'], // shared presets
+  /* updated by release-tool */
+  baseBranchPatterns: ['master'],
+}
+`,
+			branches: []string{"master", "release-2.10"},
+			securityOnly: []renovate.SecurityOnlyBranch{
+				{Branch: "release-2.10", Reason: "end of life 2026-03-01"},
+			},
+			expected: `// renovate config
+{
+  extends: ['config:recommended'], // shared presets
+  /* updated by release-tool */
+  baseBranchPatterns: ["master", "release-2.10"],
+  "packageRules": [
+    {
+      "description": "Managed by release-tool: only security updates on release-2.10 (end of life 2026-03-01)",
+      "matchBaseBranches": [
+        "release-2.10"
+      ],
+      "matchPackageNames": [
+        "*"
+      ],
+      "enabled": false
+    }
+  ],
+}
+`,
+			changes: renovate.Changes{Added: []string{"release-2.10"}, Removed: []string{}, SecurityOnly: []string{"release-2.10"}},
+		},
+		{
+			desc: "replaces managed package rules and keeps the others",
+			content: `{
+  "baseBranchPatterns": ["master", "release-2.10"],
+  "packageRules": [
+    {
+      "description": "Managed by release-tool: only security updates on release-2.9 (end of life 2025-12-01)",
+      "matchBaseBranches": ["release-2.9"],
+      "matchPackageNames": ["*"],
+      "enabled": false
+    },
+    // group go updates
+    {
+      "matchManagers": ["gomod"],
+      "groupName": "go"
+    }
+  ]
+}
+`,
+			branches: []string{"master", "release-2.10"},
+			securityOnly: []renovate.SecurityOnlyBranch{
+				{Branch: "release-2.10", Reason: "security fixes only"},
+			},
+			expected: `{
+  "baseBranchPatterns": ["master", "release-2.10"],
+  "packageRules": [
+    // group go updates
+    {
+      "matchManagers": ["gomod"],
+      "groupName": "go"
+    },
+    {
+      "description": "Managed by release-tool: only security updates on release-2.10 (security fixes only)",
+      "matchBaseBranches": [
+        "release-2.10"
+      ],
+      "matchPackageNames": [
+        "*"
+      ],
+      "enabled": false
+    }
+  ]
+}
+`,
+			changes: renovate.Changes{Added: []string{}, Removed: []string{}, SecurityOnly: []string{"release-2.10"}},
+		},
+		{
+			desc: "removes managed package rules",
+			content: `{
+  "baseBranchPatterns": ["master"],
+  "packageRules": [
+    {
+      "description": "Managed by release-tool: only security updates on release-2.9 (end of life 2025-12-01)",
+      "enabled": false
+    }
+  ]
+}`,
+			branches: []string{"master"},
+			expected: `{
+  "baseBranchPatterns": ["master"],
+  "packageRules": []
+}`,
+			changes: renovate.Changes{Added: []string{}, Removed: []string{}, SecurityOnly: []string{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			res, changes, err := renovate.Update([]byte(tt.content), tt.branches, tt.securityOnly)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(res) != tt.expected {
+				t.Errorf("got:\n%s\nexpected:\n%s", res, tt.expected)
+			}
+			if !reflect.DeepEqual(changes, tt.changes) {
+				t.Errorf("got changes %+v expected %+v", changes, tt.changes)
+			}
+			// updating again changes nothing
+			again, _, err := renovate.Update(res, tt.branches, tt.securityOnly)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(again) != string(res) {
+				t.Errorf("second update isn't a no-op, got:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestUpdateErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		content string
+	}{
+		{desc: "not an object", content: `["master"]`},
+		{desc: "base branches isn't an array", content: `{"baseBranchPatterns": "master"}`},
+		{desc: "unterminated object", content: `{"baseBranchPatterns": ["master"]`},
+		{desc: "missing colon", content: `{"baseBranchPatterns" ["master"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if _, _, err := renovate.Update([]byte(tt.content), []string{"master"}, nil); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
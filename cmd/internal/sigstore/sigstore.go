@@ -0,0 +1,172 @@
+package sigstore
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	InTotoPayloadType = "application/vnd.in-toto+json"
+	// CosignSignatureAnnotation is the OCI layer annotation cosign stores the base64 signature in.
+	CosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// LoadPublicKey parses a PEM encoded PKIX public key (the format of `cosign.pub`).
+func LoadPublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in public key")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// VerifySignature checks sig is a signature of msg by pub using the hash algorithm cosign uses for this key type.
+func VerifySignature(pub crypto.PublicKey, msg, sig []byte) error {
+	digest := sha256.Sum256(msg)
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, digest[:], sig) {
+			return ErrInvalidSignature
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, msg, sig) {
+			return ErrInvalidSignature
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return nil
+}
+
+// Envelope is a DSSE envelope, each line of a `.intoto.jsonl` file is one of these.
+type Envelope struct {
+	PayloadType string              `json:"payloadType"`
+	Payload     string              `json:"payload"`
+	Signatures  []EnvelopeSignature `json:"signatures"`
+}
+
+type EnvelopeSignature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// ParseEnvelopes reads a `.intoto.jsonl` file.
+func ParseEnvelopes(r io.Reader) ([]Envelope, error) {
+	var out []Envelope
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e Envelope
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("invalid DSSE envelope: %w", err)
+		}
+		out = append(out, e)
+	}
+	return out, scanner.Err()
+}
+
+// pae is the DSSE pre-authentication encoding which is what's actually signed.
+func pae(payloadType string, payload []byte) []byte {
+	return fmt.Appendf(nil, "DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)
+}
+
+// Verify checks that at least one of the envelope signatures was made by pub.
+func (e Envelope) Verify(pub crypto.PublicKey) error {
+	payload, err := base64.StdEncoding.DecodeString(e.Payload)
+	if err != nil {
+		return fmt.Errorf("invalid envelope payload: %w", err)
+	}
+	msg := pae(e.PayloadType, payload)
+	for _, s := range e.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			continue
+		}
+		if VerifySignature(pub, msg, sig) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no envelope signature matches the public key", ErrInvalidSignature)
+}
+
+// Statement is an in-toto statement, only the subjects are decoded as we don't check the predicate.
+type Statement struct {
+	Type          string    `json:"_type"`
+	PredicateType string    `json:"predicateType"`
+	Subject       []Subject `json:"subject"`
+}
+
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Statement decodes the in-toto statement wrapped by the envelope.
+func (e Envelope) Statement() (Statement, error) {
+	var out Statement
+	if e.PayloadType != InTotoPayloadType {
+		return out, fmt.Errorf("unexpected payload type %q", e.PayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(e.Payload)
+	if err != nil {
+		return out, fmt.Errorf("invalid envelope payload: %w", err)
+	}
+	if err := json.Unmarshal(payload, &out); err != nil {
+		return out, fmt.Errorf("invalid in-toto statement: %w", err)
+	}
+	return out, nil
+}
+
+// SimpleSigning is the payload cosign signs for container images.
+type SimpleSigning struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// VerifyCosignPayload checks the signature of a cosign simple signing payload and that it is for the image with this digest.
+func VerifyCosignPayload(pub crypto.PublicKey, payload []byte, b64Sig string, imageDigest string) error {
+	sig, err := base64.StdEncoding.DecodeString(b64Sig)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if err := VerifySignature(pub, payload, sig); err != nil {
+		return err
+	}
+	var s SimpleSigning
+	if err := json.Unmarshal(payload, &s); err != nil {
+		return fmt.Errorf("invalid cosign payload: %w", err)
+	}
+	if s.Critical.Image.DockerManifestDigest != imageDigest {
+		return fmt.Errorf("cosign payload is for %s and not %s", s.Critical.Image.DockerManifestDigest, imageDigest)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package sigstore_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kumahq/ci-tools/cmd/internal/sigstore"
+)
+
+func newKey(t *testing.T) (*ecdsa.PrivateKey, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, msg []byte) string {
+	t.Helper()
+	digest := sha256.Sum256(msg)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func TestEnvelope(t *testing.T) {
+	key, pubPEM := newKey(t)
+	pub, err := sigstore.LoadPublicKey(pubPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := `{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://slsa.dev/provenance/v0.2","subject":[{"name":"kuma-2.11.8-linux-amd64.tar.gz","digest":{"sha256":"abcd"}}]}`
+	pae := fmt.Sprintf("DSSEv1 %d %s %d %s", len(sigstore.InTotoPayloadType), sigstore.InTotoPayloadType, len(payload), payload)
+	line := fmt.Sprintf(`{"payloadType":%q,"payload":%q,"signatures":[{"keyid":"","sig":%q}]}`,
+		sigstore.InTotoPayloadType, base64.StdEncoding.EncodeToString([]byte(payload)), sign(t, key, []byte(pae)))
+
+	envelopes, err := sigstore.ParseEnvelopes(strings.NewReader(line + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(envelopes) != 1 {
+		t.Fatalf("expected 1 envelope got %d", len(envelopes))
+	}
+	if err := envelopes[0].Verify(pub); err != nil {
+		t.Errorf("expected valid signature got %v", err)
+	}
+	statement, err := envelopes[0].Statement()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statement.Subject) != 1 || statement.Subject[0].Name != "kuma-2.11.8-linux-amd64.tar.gz" || statement.Subject[0].Digest["sha256"] != "abcd" {
+		t.Errorf("unexpected subjects %+v", statement.Subject)
+	}
+
+	_, otherPEM := newKey(t)
+	other, _ := sigstore.LoadPublicKey(otherPEM)
+	if err := envelopes[0].Verify(other); !errors.Is(err, sigstore.ErrInvalidSignature) {
+		t.Errorf("expected invalid signature with another key got %v", err)
+	}
+}
+
+func TestVerifyCosignPayload(t *testing.T) {
+	key, pubPEM := newKey(t)
+	pub, _ := sigstore.LoadPublicKey(pubPEM)
+	payload := []byte(`{"critical":{"identity":{"docker-reference":"index.docker.io/kumahq/kuma-cp"},"image":{"docker-manifest-digest":"sha256:1234"},"type":"cosign container image signature"},"optional":null}`)
+	sig := sign(t, key, payload)
+
+	if err := sigstore.VerifyCosignPayload(pub, payload, sig, "sha256:1234"); err != nil {
+		t.Errorf("expected valid payload got %v", err)
+	}
+	if err := sigstore.VerifyCosignPayload(pub, payload, sig, "sha256:5678"); err == nil {
+		t.Error("expected an error for a payload signing another digest")
+	}
+	if err := sigstore.VerifyCosignPayload(pub, append(payload, ' '), sig, "sha256:1234"); !errors.Is(err, sigstore.ErrInvalidSignature) {
+		t.Errorf("expected invalid signature got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package versionfile
+
+import (
+	"strings"
+)
+
+// EndOfLifeCycle is a release cycle in the schema of https://endoflife.date.
+type EndOfLifeCycle struct {
+	Cycle       string `json:"cycle" yaml:"cycle"`
+	ReleaseDate string `json:"releaseDate" yaml:"releaseDate"`
+	// EOL is the end of life date or false when it's unknown
+	EOL               any    `json:"eol" yaml:"eol"`
+	Latest            string `json:"latest" yaml:"latest"`
+	LatestReleaseDate string `json:"latestReleaseDate,omitempty" yaml:"latestReleaseDate,omitempty"`
+	LTS               bool   `json:"lts" yaml:"lts"`
+	// Support is the end of active support, omitted when there's no separate active support phase
+	Support string `json:"support,omitempty" yaml:"support,omitempty"`
+}
+
+// EndOfLifeCycles converts entries to endoflife.date release cycles newest first, versions not released yet are skipped.
+func EndOfLifeCycles(entries []VersionEntry) []EndOfLifeCycle {
+	out := []EndOfLifeCycle{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.ReleaseDate == "" {
+			continue
+		}
+		c := EndOfLifeCycle{
+			Cycle:             strings.TrimSuffix(e.Release, ".x"),
+			ReleaseDate:       e.ReleaseDate,
+			EOL:               false,
+			Latest:            e.Version,
+			LatestReleaseDate: e.LatestReleaseDate,
+			LTS:               e.LTS,
+			Support:           e.EndOfActiveSupportDate,
+		}
+		if e.EndOfLifeDate != "" {
+			c.EOL = e.EndOfLifeDate
+		}
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,24 @@
+package versionfile_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kumahq/ci-tools/cmd/internal/versionfile"
+)
+
+func TestEndOfLifeCycles(t *testing.T) {
+	entries := []versionfile.VersionEntry{
+		{Version: "1.2.3", Release: "1.2.x", ReleaseDate: "2020-01-01", EndOfLifeDate: "2021-01-01", LatestReleaseDate: "2020-06-01", LTS: true},
+		{Version: "1.3.0", Release: "1.3.x", ReleaseDate: "2020-07-01", LatestReleaseDate: "2020-07-01", EndOfActiveSupportDate: "2020-12-01"},
+		{Version: "1.4.0", Release: "1.4.x"},
+		{Version: "preview", Release: "1.5.x", Label: "dev"},
+	}
+	expected := []versionfile.EndOfLifeCycle{
+		{Cycle: "1.3", ReleaseDate: "2020-07-01", EOL: false, Latest: "1.3.0", LatestReleaseDate: "2020-07-01", Support: "2020-12-01"},
+		{Cycle: "1.2", ReleaseDate: "2020-01-01", EOL: "2021-01-01", Latest: "1.2.3", LatestReleaseDate: "2020-06-01", LTS: true},
+	}
+	if res := versionfile.EndOfLifeCycles(entries); !reflect.DeepEqual(res, expected) {
+		t.Errorf("got %+v expected %+v", res, expected)
+	}
+}
@@ -0,0 +1,115 @@
+package versionfile
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// icsDomain makes UIDs globally unique, it must never change so calendar subscriptions update events in place.
+const icsDomain = "release-tool.kumahq.github.io"
+
+// icsEpoch is the DTSTAMP of a calendar without release date and the start of SEQUENCE.
+var icsEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// icsSequenceDays bounds the days from the release date to an event in SEQUENCE so releases always increase it.
+const icsSequenceDays = 10000
+
+// WriteICS writes an RFC 5545 calendar with an all-day event for the release date and the end of life of each entry.
+// Everything is derived from the entries so the same entries always produce the same calendar.
+// The end of life of an entry can change without a release of this entry (e.g. when a later minor is released or
+// its metadata is edited) so DTSTAMP is the newest release date of the whole calendar and SEQUENCE is this stamp in
+// days times icsSequenceDays plus the days from the release date of the entry to the event: it increases with every
+// release and when an event moves later. An end of life moved earlier without any release lowers it, the entries
+// don't record when their metadata was edited.
+func WriteICS(w io.Writer, entries []VersionEntry) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//kumahq//release-tool//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+	}
+	stamp := icsEpoch
+	for _, e := range entries {
+		for _, d := range []string{e.LatestReleaseDate, e.ReleaseDate} {
+			if t, err := time.Parse(time.DateOnly, d); err == nil && t.After(stamp) {
+				stamp = t
+			}
+		}
+	}
+	days := func(from, to time.Time) int {
+		return max(int(to.Sub(from).Hours()/24), 0)
+	}
+	for _, e := range entries {
+		releaseDate, err := time.Parse(time.DateOnly, e.ReleaseDate)
+		if err != nil {
+			releaseDate = stamp
+		}
+		for _, ev := range []struct {
+			kind    string
+			date    string
+			summary string
+		}{
+			{kind: "release", date: e.ReleaseDate, summary: fmt.Sprintf("%s %s released", e.Edition, e.Release)},
+			{kind: "eol", date: e.EndOfLifeDate, summary: fmt.Sprintf("%s %s end of life", e.Edition, e.Release)},
+		} {
+			if ev.date == "" {
+				continue
+			}
+			day, err := time.Parse(time.DateOnly, ev.date)
+			if err != nil {
+				return fmt.Errorf("invalid date of %s %s: %w", e.Edition, e.Release, err)
+			}
+			description := fmt.Sprintf("Latest version: %s\nBranch: %s", e.Version, e.Branch)
+			if e.LTS {
+				description += "\nLTS"
+			}
+			lines = append(lines,
+				"BEGIN:VEVENT",
+				fmt.Sprintf("UID:%s-%s-%s@%s", e.Edition, e.Release, ev.kind, icsDomain),
+				"DTSTAMP:"+stamp.Format("20060102T150405Z"),
+				fmt.Sprintf("SEQUENCE:%d", days(icsEpoch, stamp)*icsSequenceDays+min(days(releaseDate, day), icsSequenceDays-1)),
+				"DTSTART;VALUE=DATE:"+day.Format("20060102"),
+				"DTEND;VALUE=DATE:"+day.AddDate(0, 0, 1).Format("20060102"),
+				"SUMMARY:"+icsEscape(ev.summary),
+				"DESCRIPTION:"+icsEscape(description),
+				"TRANSP:TRANSPARENT",
+				"END:VEVENT",
+			)
+		}
+	}
+	lines = append(lines, "END:VCALENDAR")
+	sb := &strings.Builder{}
+	for _, l := range lines {
+		sb.WriteString(icsFold(l))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// icsEscape escapes a TEXT value (RFC 5545 3.3.11).
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// icsFold ends a content line with CRLF and folds it in lines of at most 75 octets without splitting UTF-8 characters (RFC 5545 3.1).
+func icsFold(line string) string {
+	sb := &strings.Builder{}
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		sb.WriteString(line[:cut])
+		sb.WriteString("\r\n ")
+		line = line[cut:]
+		// continuation lines start with a space
+		limit = 74
+	}
+	sb.WriteString(line)
+	sb.WriteString("\r\n")
+	return sb.String()
+}
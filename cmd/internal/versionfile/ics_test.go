@@ -0,0 +1,119 @@
+package versionfile_test
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kumahq/ci-tools/cmd/internal/versionfile"
+)
+
+func TestWriteICS(t *testing.T) {
+	entries := []versionfile.VersionEntry{
+		{Edition: "kuma", Version: "2.11.1", Release: "2.11.x", ReleaseDate: "2025-06-20", EndOfLifeDate: "2027-06-20", Branch: "release-2.11", LTS: true, LatestReleaseDate: "2025-08-01"},
+		{Edition: "kuma", Version: "preview", Release: "2.12.x", Branch: "master", Label: "dev"},
+	}
+	b := &bytes.Buffer{}
+	if err := versionfile.WriteICS(b, entries); err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//kumahq//release-tool//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"BEGIN:VEVENT",
+		"UID:kuma-2.11.x-release@release-tool.kumahq.github.io",
+		"DTSTAMP:20250801T000000Z",
+		"SEQUENCE:93440000",
+		"DTSTART;VALUE=DATE:20250620",
+		"DTEND;VALUE=DATE:20250621",
+		"SUMMARY:kuma 2.11.x released",
+		`DESCRIPTION:Latest version: 2.11.1\nBranch: release-2.11\nLTS`,
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:kuma-2.11.x-eol@release-tool.kumahq.github.io",
+		"DTSTAMP:20250801T000000Z",
+		"SEQUENCE:93440730",
+		"DTSTART;VALUE=DATE:20270620",
+		"DTEND;VALUE=DATE:20270621",
+		"SUMMARY:kuma 2.11.x end of life",
+		`DESCRIPTION:Latest version: 2.11.1\nBranch: release-2.11\nLTS`,
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	if b.String() != expected {
+		t.Errorf("got:\n%q\nexpected:\n%q", b.String(), expected)
+	}
+}
+
+func TestWriteICSSequenceMovesForward(t *testing.T) {
+	sequence := func(entries ...versionfile.VersionEntry) int {
+		t.Helper()
+		b := &bytes.Buffer{}
+		if err := versionfile.WriteICS(b, entries); err != nil {
+			t.Fatal(err)
+		}
+		eol := false
+		for _, l := range strings.Split(b.String(), "\r\n") {
+			eol = eol || strings.HasPrefix(l, "UID:kuma-2.11.x-eol@")
+			if eol && strings.HasPrefix(l, "SEQUENCE:") {
+				n, err := strconv.Atoi(strings.TrimPrefix(l, "SEQUENCE:"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return n
+			}
+		}
+		t.Fatalf("no end of life sequence in %q", b.String())
+		return 0
+	}
+	entry := versionfile.VersionEntry{Edition: "kuma", Release: "2.11.x", ReleaseDate: "2025-06-20", EndOfLifeDate: "2027-06-20", LatestReleaseDate: "2025-08-01"}
+	before := sequence(entry)
+
+	extended := entry
+	// The metadata of a published release is edited to extend the end of life
+	extended.EndOfLifeDate = "2027-12-20"
+	if after := sequence(extended); after <= before {
+		t.Errorf("expected the sequence to increase from %d when only the end of life changes got %d", before, after)
+	}
+	patched := entry
+	// A later patch moves the end of life earlier
+	patched.EndOfLifeDate, patched.LatestReleaseDate = "2026-12-31", "2025-09-01"
+	if after := sequence(patched); after <= before {
+		t.Errorf("expected the sequence to increase from %d with a patch got %d", before, after)
+	}
+	shortened := entry
+	// The release of a later minor moves the end of life earlier (--eol-after-minors)
+	shortened.EndOfLifeDate = "2026-03-01"
+	if after := sequence(shortened, versionfile.VersionEntry{Edition: "kuma", Release: "2.13.x", ReleaseDate: "2026-03-01"}); after <= before {
+		t.Errorf("expected the sequence to increase from %d with a later minor got %d", before, after)
+	}
+}
+
+func TestWriteICSFoldsLongLines(t *testing.T) {
+	entries := []versionfile.VersionEntry{
+		{Edition: strings.Repeat("é", 50), Release: "1.0.x", ReleaseDate: "2025-01-01"},
+	}
+	b := &bytes.Buffer{}
+	if err := versionfile.WriteICS(b, entries); err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range strings.Split(b.String(), "\r\n") {
+		if len(l) > 75 {
+			t.Errorf("line longer than 75 octets: %q", l)
+		}
+		if !utf8.ValidString(l) {
+			t.Errorf("line splits a character: %q", l)
+		}
+	}
+	if !strings.Contains(b.String(), "\r\n ") {
+		t.Errorf("expected folded lines")
+	}
+}
@@ -0,0 +1,44 @@
+package versionfile
+
+import (
+	"strings"
+	"time"
+)
+
+// MatrixEntry is a version in a GitHub Actions `strategy.matrix`.
+type MatrixEntry struct {
+	Branch string `json:"branch"`
+	// Version is the minor of the version (e.g. `2.11`)
+	Version string `json:"version"`
+	// Latest is the latest patch released, empty for the version in development
+	Latest string `json:"latest"`
+	LTS    bool   `json:"lts"`
+	// DaysUntilEOL is nil when the end of life isn't known
+	DaysUntilEOL *int `json:"daysUntilEOL"`
+}
+
+// Matrix is a GitHub Actions matrix with a job for each entry of Include, it's used with `fromJSON`.
+type Matrix struct {
+	Include []MatrixEntry `json:"include"`
+}
+
+// ActiveMatrix returns the matrix of the versions that aren't end of life at `at`.
+func ActiveMatrix(entries []VersionEntry, at time.Time) Matrix {
+	out := Matrix{Include: []MatrixEntry{}}
+	for _, e := range entries {
+		if e.Phase(at) == PhaseEOL {
+			continue
+		}
+		m := MatrixEntry{
+			Branch:       e.Branch,
+			Version:      strings.TrimSuffix(e.Release, ".x"),
+			LTS:          e.LTS,
+			DaysUntilEOL: e.DaysUntilEOL(at),
+		}
+		if e.ReleaseDate != "" {
+			m.Latest = e.Version
+		}
+		out.Include = append(out.Include, m)
+	}
+	return out
+}
@@ -0,0 +1,28 @@
+package versionfile_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kumahq/ci-tools/cmd/internal/versionfile"
+)
+
+func TestActiveMatrix(t *testing.T) {
+	entries := []versionfile.VersionEntry{
+		{Version: "1.2.3", Release: "1.2.x", Branch: "release-1.2", ReleaseDate: "2020-01-01", EndOfLifeDate: "2021-01-01"},
+		{Version: "1.3.2", Release: "1.3.x", Branch: "release-1.3", ReleaseDate: "2020-07-01", EndOfLifeDate: "2021-07-01", LTS: true},
+		{Version: "1.4.0", Release: "1.4.x", Branch: "release-1.4", ReleaseDate: "2020-12-01"},
+		{Version: "preview", Release: "1.5.x", Branch: "master", Label: "dev"},
+	}
+	days := 181
+	expected := versionfile.Matrix{Include: []versionfile.MatrixEntry{
+		{Branch: "release-1.3", Version: "1.3", Latest: "1.3.2", LTS: true, DaysUntilEOL: &days},
+		{Branch: "release-1.4", Version: "1.4", Latest: "1.4.0"},
+		{Branch: "master", Version: "1.5"},
+	}}
+	res := versionfile.ActiveMatrix(entries, time.Date(2021, 1, 1, 15, 0, 0, 0, time.UTC))
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("got %+v expected %+v", res, expected)
+	}
+}
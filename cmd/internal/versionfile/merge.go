@@ -0,0 +1,56 @@
+package versionfile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// VersionRef points to the version of an edition.
+type VersionRef struct {
+	Edition string `yaml:"edition" json:"edition"`
+	Release string `yaml:"release" json:"release"`
+}
+
+// Edition is the versions of an edition to merge with other editions.
+type Edition struct {
+	Name    string
+	Entries []VersionEntry
+	// BasedOn is the name of the edition this one is built from (e.g. an enterprise edition built from the open source one),
+	// each version is linked to the version of BasedOn with the same release.
+	BasedOn string
+}
+
+// MergeEditions returns the versions of all editions sorted by release, versions with the same release are in the order of editions.
+func MergeEditions(editions []Edition) ([]VersionEntry, error) {
+	releases := map[string]map[string]bool{}
+	for _, e := range editions {
+		if _, ok := releases[e.Name]; ok {
+			return nil, fmt.Errorf("edition %s is set more than once", e.Name)
+		}
+		releases[e.Name] = map[string]bool{}
+		for _, v := range e.Entries {
+			releases[e.Name][v.Release] = true
+		}
+	}
+	var out []VersionEntry
+	for _, e := range editions {
+		if e.BasedOn != "" && releases[e.BasedOn] == nil {
+			return nil, fmt.Errorf("edition %s is based on %s which isn't merged", e.Name, e.BasedOn)
+		}
+		for _, v := range e.Entries {
+			if e.BasedOn != "" && releases[e.BasedOn][v.Release] {
+				v.BasedOn = &VersionRef{Edition: e.BasedOn, Release: v.Release}
+			}
+			out = append(out, v)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		ri := semver.MustParse(strings.ReplaceAll(out[i].Release, "x", "0"))
+		rj := semver.MustParse(strings.ReplaceAll(out[j].Release, "x", "0"))
+		return ri.LessThan(rj)
+	})
+	return out, nil
+}
@@ -0,0 +1,56 @@
+package versionfile_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kumahq/ci-tools/cmd/internal/versionfile"
+)
+
+func TestMergeEditions(t *testing.T) {
+	oss := []versionfile.VersionEntry{
+		{Edition: "kuma", Version: "2.10.1", Release: "2.10.x"},
+		{Edition: "kuma", Version: "2.11.0", Release: "2.11.x"},
+		{Edition: "kuma", Version: "preview", Release: "2.12.x", Label: "dev"},
+	}
+	enterprise := []versionfile.VersionEntry{
+		{Edition: "kong-mesh", Version: "2.9.4", Release: "2.9.x"},
+		{Edition: "kong-mesh", Version: "2.11.2", Release: "2.11.x"},
+		{Edition: "kong-mesh", Version: "preview", Release: "2.12.x", Label: "dev"},
+	}
+	res, err := versionfile.MergeEditions([]versionfile.Edition{
+		{Name: "kuma", Entries: oss},
+		{Name: "kong-mesh", Entries: enterprise, BasedOn: "kuma"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []versionfile.VersionEntry{
+		{Edition: "kong-mesh", Version: "2.9.4", Release: "2.9.x"},
+		{Edition: "kuma", Version: "2.10.1", Release: "2.10.x"},
+		{Edition: "kuma", Version: "2.11.0", Release: "2.11.x"},
+		{Edition: "kong-mesh", Version: "2.11.2", Release: "2.11.x", BasedOn: &versionfile.VersionRef{Edition: "kuma", Release: "2.11.x"}},
+		{Edition: "kuma", Version: "preview", Release: "2.12.x", Label: "dev"},
+		{Edition: "kong-mesh", Version: "preview", Release: "2.12.x", Label: "dev", BasedOn: &versionfile.VersionRef{Edition: "kuma", Release: "2.12.x"}},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("got %+v expected %+v", res, expected)
+	}
+}
+
+func TestMergeEditionsErrors(t *testing.T) {
+	tests := []struct {
+		desc     string
+		editions []versionfile.Edition
+	}{
+		{desc: "duplicate edition", editions: []versionfile.Edition{{Name: "kuma"}, {Name: "kuma"}}},
+		{desc: "based on an edition not merged", editions: []versionfile.Edition{{Name: "kong-mesh", BasedOn: "kuma"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if _, err := versionfile.MergeEditions(tt.editions); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
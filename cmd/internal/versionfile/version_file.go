@@ -3,7 +3,6 @@ package versionfile
 import (
 	"fmt"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
@@ -13,16 +12,24 @@ import (
 )
 
 type VersionEntry struct {
-	Edition         string `yaml:"edition"`
-	Version         string `yaml:"version"`
-	Release         string `yaml:"release"`
-	Latest          bool   `yaml:"latest,omitempty"`
-	ReleaseDate     string `yaml:"releaseDate,omitempty"`
-	EndOfLifeDate   string `yaml:"endOfLifeDate,omitempty"`
-	Branch          string `yaml:"branch"`
-	Label           string `yaml:"label,omitempty"`
-	LTS             bool   `yaml:"lts,omitempty"`
-	ExtensionMonths int    `yaml:"extensionMonths,omitempty"`
+	Edition     string `yaml:"edition" json:"edition"`
+	Version     string `yaml:"version" json:"version"`
+	Release     string `yaml:"release" json:"release"`
+	Latest      bool   `yaml:"latest,omitempty" json:"latest,omitempty"`
+	ReleaseDate string `yaml:"releaseDate,omitempty" json:"releaseDate,omitempty"`
+	// EndOfActiveSupportDate is when the version stops receiving bug fixes, it only gets security fixes until EndOfLifeDate after it.
+	EndOfActiveSupportDate string `yaml:"endOfActiveSupportDate,omitempty" json:"endOfActiveSupportDate,omitempty"`
+	EndOfLifeDate          string `yaml:"endOfLifeDate,omitempty" json:"endOfLifeDate,omitempty"`
+	Branch                 string `yaml:"branch" json:"branch"`
+	Label                  string `yaml:"label,omitempty" json:"label,omitempty"`
+	LTS                    bool   `yaml:"lts,omitempty" json:"lts,omitempty"`
+	ExtensionMonths        int    `yaml:"extensionMonths,omitempty" json:"extensionMonths,omitempty"`
+	SupportTier            string `yaml:"supportTier,omitempty" json:"supportTier,omitempty"`
+	SecurityOnly           bool   `yaml:"securityOnly,omitempty" json:"securityOnly,omitempty"`
+	// BasedOn is the version of another edition this version is built from when editions are merged.
+	BasedOn *VersionRef `yaml:"basedOn,omitempty" json:"basedOn,omitempty"`
+	// LatestReleaseDate is the release date of Version, it's not in the versions file but used by other formats.
+	LatestReleaseDate string `yaml:"-" json:"-"`
 }
 
 func (v VersionEntry) Less(o VersionEntry) bool {
@@ -31,7 +38,68 @@ func (v VersionEntry) Less(o VersionEntry) bool {
 	return vV.LessThan(vO)
 }
 
-func BuildVersionEntry(edition string, releaseName string, lifetimeMonths int, ltslifetimeMonths int, releases []github.GQLRelease) (VersionEntry, error) {
+// SupportPhase is where a version is in its lifecycle at a given date.
+type SupportPhase string
+
+const (
+	// PhasePreview is a version not released yet.
+	PhasePreview SupportPhase = "preview"
+	// PhaseActive is a version getting bug and security fixes.
+	PhaseActive SupportPhase = "active"
+	// PhaseSecurity is a version only getting security fixes.
+	PhaseSecurity SupportPhase = "security"
+	// PhaseEOL is a version not supported anymore.
+	PhaseEOL SupportPhase = "eol"
+)
+
+// DaysUntilEOL returns the number of days from the day of at to the end of life, negative once it's EOL and nil if it's unknown.
+func (v VersionEntry) DaysUntilEOL(at time.Time) *int {
+	eol, err := time.Parse(time.DateOnly, v.EndOfLifeDate)
+	if err != nil {
+		return nil
+	}
+	days := int(eol.Sub(at.Truncate(24*time.Hour)).Hours() / 24)
+	return &days
+}
+
+// Phase returns the support phase of the version at the date at.
+func (v VersionEntry) Phase(at time.Time) SupportPhase {
+	if v.ReleaseDate == "" {
+		return PhasePreview
+	}
+	if t, err := time.Parse(time.DateOnly, v.EndOfLifeDate); err == nil && !at.Before(t) {
+		return PhaseEOL
+	}
+	if v.SecurityOnly {
+		return PhaseSecurity
+	}
+	if t, err := time.Parse(time.DateOnly, v.EndOfActiveSupportDate); err == nil && !at.Before(t) {
+		return PhaseSecurity
+	}
+	return PhaseActive
+}
+
+// SupportPolicy are the rules computing the end of active support and end of life of versions.
+// The end of life set in the metadata of a release always wins.
+type SupportPolicy struct {
+	// LifetimeMonths is the time from the release date to the end of life (LTSLifetimeMonths for LTS versions),
+	// the extension months of the release are added on top.
+	LifetimeMonths    int
+	LTSLifetimeMonths int
+	// EOLAfterMinors if not 0 makes the version end of life when the Nth next minor is released
+	// instead of after its lifetime (which is still used while the Nth next minor isn't released).
+	// LTS and extended versions are end of life at the latest of the two.
+	EOLAfterMinors int
+	// ActiveSupportMonths if not 0 is the time from the release date to the end of active support.
+	ActiveSupportMonths int
+	// ActiveSupportMinors if not 0 ends active support when the Nth next minor is released.
+	// When both active support rules apply the earliest date wins.
+	ActiveSupportMinors int
+}
+
+// BuildVersionEntry builds the entry of a minor version from all its releases,
+// nextMinors are the release dates of the next minors (in order) used by the policy rules based on minors.
+func BuildVersionEntry(edition string, releaseName string, policy SupportPolicy, releases []github.ParsedRelease, nextMinors []time.Time) (VersionEntry, error) {
 	out := VersionEntry{
 		Release: releaseName,
 		Edition: edition,
@@ -41,35 +109,136 @@ func BuildVersionEntry(edition string, releaseName string, lifetimeMonths int, l
 		out.Latest = out.Latest || r.IsLatest
 	}
 	sort.Slice(releases, func(i, j int) bool {
-		iv, _ := strconv.Atoi(strings.Split(releases[i].Name, ".")[2])
-		jv, _ := strconv.Atoi(strings.Split(releases[j].Name, ".")[2])
-		return iv < jv
+		return releases[i].Version.LessThan(releases[j].Version)
 	})
+	var eol, activeEnd time.Time
 	if releases[0].IsReleased() {
-		lifetime := lifetimeMonths
-		if releases[0].IsLTS() {
+		metadata, err := releases[0].Metadata()
+		if err != nil {
+			return out, err
+		}
+		lifetime := policy.LifetimeMonths
+		if metadata.LTS {
 			out.LTS = true
-			lifetime = ltslifetimeMonths
+			lifetime = policy.LTSLifetimeMonths
 		}
-		if ext := releases[0].ExtendedMonths(); ext > 0 {
+		if ext := metadata.ExtensionMonths; ext > 0 {
 			lifetime += ext
 			out.ExtensionMonths = ext
 		}
-		releaseDate, err := releases[0].ExtractReleaseDate()
+		releaseDate, err := releases[0].ReleaseDate()
 		if err != nil {
 			return out, fmt.Errorf("failed to extract release date for %s because of: %s", releases[0].Name, err.Error())
 		}
-		EOLDate := releaseDate.AddDate(0, lifetime, 0)
 		out.ReleaseDate = releaseDate.Format(time.DateOnly)
-		out.EndOfLifeDate = EOLDate.Format(time.DateOnly)
+		eol = releaseDate.AddDate(0, lifetime, 0)
+		if n := policy.EOLAfterMinors; n > 0 && len(nextMinors) >= n {
+			// LTS and extended versions keep at least their lifetime
+			if !out.LTS && out.ExtensionMonths == 0 || nextMinors[n-1].After(eol) {
+				eol = nextMinors[n-1]
+			}
+		}
+		if policy.ActiveSupportMonths > 0 {
+			activeEnd = releaseDate.AddDate(0, policy.ActiveSupportMonths, 0)
+		}
+		if n := policy.ActiveSupportMinors; n > 0 && len(nextMinors) >= n && (activeEnd.IsZero() || nextMinors[n-1].Before(activeEnd)) {
+			activeEnd = nextMinors[n-1]
+		}
 	}
 	// Retrieve the latest release that is not a draft.
+	// The end of life, support tier and security only status can change during the life of a version so the latest patch setting them wins.
 	latestRelease := releases[len(releases)-1]
 	for i := range releases {
-		if releases[i].IsReleased() {
-			latestRelease = releases[i]
+		if !releases[i].IsReleased() {
+			continue
+		}
+		latestRelease = releases[i]
+		metadata, err := releases[i].Metadata()
+		if err != nil {
+			return out, err
+		}
+		latestDate, err := releases[i].ReleaseDate()
+		if err != nil {
+			return out, err
+		}
+		out.LatestReleaseDate = latestDate.Format(time.DateOnly)
+		if !metadata.EndOfLife.IsZero() {
+			eol = metadata.EndOfLife
+		}
+		if metadata.SupportTier != "" {
+			out.SupportTier = metadata.SupportTier
 		}
+		out.SecurityOnly = out.SecurityOnly || metadata.SecurityOnly
+	}
+	if !eol.IsZero() {
+		out.EndOfLifeDate = eol.Format(time.DateOnly)
+		if activeEnd.After(eol) {
+			activeEnd = eol
+		}
+	}
+	if !activeEnd.IsZero() {
+		out.EndOfActiveSupportDate = activeEnd.Format(time.DateOnly)
+	}
+	out.Version = latestRelease.Version.String()
+	return out, nil
+}
+
+// BuildVersionEntries groups releases by minor version and builds the entry of each of them, sorted by version.
+func BuildVersionEntries(edition string, policy SupportPolicy, releases []github.ParsedRelease) ([]VersionEntry, error) {
+	byMinor := map[string][]github.ParsedRelease{}
+	var minors []string
+	for _, r := range releases {
+		minor := fmt.Sprintf("%d.%d.x", r.Version.Major(), r.Version.Minor())
+		if _, ok := byMinor[minor]; !ok {
+			minors = append(minors, minor)
+		}
+		byMinor[minor] = append(byMinor[minor], r)
+	}
+	sort.Slice(minors, func(i, j int) bool {
+		return byMinor[minors[i]][0].Version.LessThan(byMinor[minors[j]][0].Version)
+	})
+	// The release date of each minor is the one of its first released patch
+	var released []time.Time
+	minorIndex := map[string]int{}
+	for _, minor := range minors {
+		minorIndex[minor] = len(released)
+		group := byMinor[minor]
+		var first *github.ParsedRelease
+		for i := range group {
+			if group[i].IsReleased() && (first == nil || group[i].Version.LessThan(first.Version)) {
+				first = &group[i]
+			}
+		}
+		if first == nil {
+			continue
+		}
+		date, err := first.ReleaseDate()
+		if err != nil {
+			return nil, err
+		}
+		released = append(released, date)
+	}
+	var out []VersionEntry
+	for _, minor := range minors {
+		// minorIndex is the number of released minors before this one, skip this one if it's released too
+		next := released[minorIndex[minor]:]
+		if len(next) > 0 && hasReleased(byMinor[minor]) {
+			next = next[1:]
+		}
+		entry, err := BuildVersionEntry(edition, minor, policy, byMinor[minor], next)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, entry)
 	}
-	out.Version = strings.TrimPrefix(latestRelease.Name, "v")
 	return out, nil
 }
+
+func hasReleased(releases []github.ParsedRelease) bool {
+	for _, r := range releases {
+		if r.IsReleased() {
+			return true
+		}
+	}
+	return false
+}
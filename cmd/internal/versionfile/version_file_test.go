@@ -16,11 +16,11 @@ func TestBuildVersionEntry(t *testing.T) {
 		inReleaseName       string
 		inLifetimeMonths    int
 		inLtsLifetimeMonths int
-		inReleases          []github.GQLRelease
+		inReleases          []github.Release
 		out                 versionfile.VersionEntry
 	}
 	d1 := time.Date(2020, 12, 12, 2, 2, 2, 0, time.UTC)
-	simpleCase := func(desc string, inReleases []github.GQLRelease, out versionfile.VersionEntry) entry {
+	simpleCase := func(desc string, inReleases []github.Release, out versionfile.VersionEntry) entry {
 		return entry{
 			desc:                desc,
 			inEdition:           "mesh",
@@ -34,24 +34,24 @@ func TestBuildVersionEntry(t *testing.T) {
 	for _, v := range []entry{
 		simpleCase(
 			"no draft",
-			[]github.GQLRelease{
+			[]github.Release{
 				{Name: "1.2.0", PublishedAt: d1},
 				{Name: "1.2.1", PublishedAt: d1.Add(time.Hour * 24 * 8), IsLatest: true},
 			},
-			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", Latest: true, ReleaseDate: "2020-12-12", EndOfLifeDate: "2021-12-12", Branch: "release-1.2"},
+			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", Latest: true, ReleaseDate: "2020-12-12", EndOfLifeDate: "2021-12-12", Branch: "release-1.2", LatestReleaseDate: "2020-12-20"},
 		),
 		simpleCase(
 			"draft at end",
-			[]github.GQLRelease{
+			[]github.Release{
 				{Name: "1.2.0", PublishedAt: d1},
 				{Name: "1.2.1", PublishedAt: d1.Add(time.Hour * 24 * 8)},
 				{Name: "1.2.2", IsDraft: true},
 			},
-			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", ReleaseDate: "2020-12-12", EndOfLifeDate: "2021-12-12", Branch: "release-1.2"},
+			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", ReleaseDate: "2020-12-12", EndOfLifeDate: "2021-12-12", Branch: "release-1.2", LatestReleaseDate: "2020-12-20"},
 		),
 		simpleCase(
 			"never published uses the latest version",
-			[]github.GQLRelease{
+			[]github.Release{
 				{Name: "1.2.0", IsPrerelease: true},
 				{Name: "1.2.1", IsPrerelease: true},
 				{Name: "1.2.2", IsDraft: true},
@@ -60,76 +60,111 @@ func TestBuildVersionEntry(t *testing.T) {
 		),
 		simpleCase(
 			"single release as draft",
-			[]github.GQLRelease{
+			[]github.Release{
 				{Name: "1.2.0", IsDraft: true},
 			},
 			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.0", Release: "1.2.x", Branch: "release-1.2"},
 		),
 		simpleCase(
 			"use date from description",
-			[]github.GQLRelease{
+			[]github.Release{
 				{Name: "1.2.0", Description: "> Released on 2019/01/01"},
 			},
-			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.0", Release: "1.2.x", Latest: false, ReleaseDate: "2019-01-01", EndOfLifeDate: "2020-01-01", Branch: "release-1.2"},
+			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.0", Release: "1.2.x", Latest: false, ReleaseDate: "2019-01-01", EndOfLifeDate: "2020-01-01", Branch: "release-1.2", LatestReleaseDate: "2019-01-01"},
+		),
+		simpleCase(
+			"invalid date in description uses the published date",
+			[]github.Release{
+				{Name: "1.2.0", Description: "> Released on 2019-13-45", PublishedAt: d1},
+			},
+			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.0", Release: "1.2.x", ReleaseDate: "2020-12-12", EndOfLifeDate: "2021-12-12", Branch: "release-1.2", LatestReleaseDate: "2020-12-12"},
 		),
 		simpleCase(
 			"use lts from description",
-			[]github.GQLRelease{
+			[]github.Release{
 				{Name: "1.2.0", Description: "> LTS", PublishedAt: d1},
 				{Name: "1.2.1", Description: "foo", PublishedAt: d1.Add(time.Hour * 48)},
 			},
-			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", LTS: true, ReleaseDate: "2020-12-12", EndOfLifeDate: "2022-12-12", Branch: "release-1.2"},
+			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", LTS: true, ReleaseDate: "2020-12-12", EndOfLifeDate: "2022-12-12", Branch: "release-1.2", LatestReleaseDate: "2020-12-14"},
 		),
 		simpleCase(
 			"ignore lts from description on not the first release",
-			[]github.GQLRelease{
+			[]github.Release{
 				{Name: "1.2.1", Description: "> LTS", PublishedAt: d1.Add(time.Hour * 48)},
 				{Name: "1.2.0", Description: "foo", PublishedAt: d1},
 			},
-			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", ReleaseDate: "2020-12-12", EndOfLifeDate: "2021-12-12", Branch: "release-1.2"},
+			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", ReleaseDate: "2020-12-12", EndOfLifeDate: "2021-12-12", Branch: "release-1.2", LatestReleaseDate: "2020-12-14"},
 		),
 		simpleCase(
 			"strips v-prefix from release names",
-			[]github.GQLRelease{
+			[]github.Release{
 				{Name: "v1.2.0", PublishedAt: d1},
 				{Name: "v1.2.1", PublishedAt: d1.Add(time.Hour * 24 * 8), IsLatest: true},
 			},
-			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", Latest: true, ReleaseDate: "2020-12-12", EndOfLifeDate: "2021-12-12", Branch: "release-1.2"},
+			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", Latest: true, ReleaseDate: "2020-12-12", EndOfLifeDate: "2021-12-12", Branch: "release-1.2", LatestReleaseDate: "2020-12-20"},
 		),
 		simpleCase(
 			"extended adds months on top of regular lifetime",
-			[]github.GQLRelease{
+			[]github.Release{
 				{Name: "1.2.0", Description: "> ExtensionMonths: 6", PublishedAt: d1},
 				{Name: "1.2.1", PublishedAt: d1.Add(time.Hour * 24 * 8), IsLatest: true},
 			},
-			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", Latest: true, ReleaseDate: "2020-12-12", EndOfLifeDate: "2022-06-12", Branch: "release-1.2", ExtensionMonths: 6},
+			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", Latest: true, ReleaseDate: "2020-12-12", EndOfLifeDate: "2022-06-12", Branch: "release-1.2", ExtensionMonths: 6, LatestReleaseDate: "2020-12-20"},
+		),
+		simpleCase(
+			"extended after text in the description",
+			[]github.Release{
+				{Name: "1.2.0", Description: "We are excited to announce 1.2.0.\n\n> ExtensionMonths: 6\n\n## Changelog", PublishedAt: d1},
+			},
+			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.0", Release: "1.2.x", ReleaseDate: "2020-12-12", EndOfLifeDate: "2022-06-12", Branch: "release-1.2", ExtensionMonths: 6, LatestReleaseDate: "2020-12-12"},
 		),
 		simpleCase(
 			"extended combined with lts adds months on top of lts lifetime",
-			[]github.GQLRelease{
+			[]github.Release{
 				{Name: "1.2.0", Description: "> LTS\n> ExtensionMonths: 6", PublishedAt: d1},
 				{Name: "1.2.1", PublishedAt: d1.Add(time.Hour * 48)},
 			},
-			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", LTS: true, ReleaseDate: "2020-12-12", EndOfLifeDate: "2023-06-12", Branch: "release-1.2", ExtensionMonths: 6},
+			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", LTS: true, ReleaseDate: "2020-12-12", EndOfLifeDate: "2023-06-12", Branch: "release-1.2", ExtensionMonths: 6, LatestReleaseDate: "2020-12-14"},
 		),
 		simpleCase(
 			"extended combined with custom release date",
-			[]github.GQLRelease{
+			[]github.Release{
 				{Name: "1.2.0", Description: "> Released on 2019/01/01\n> ExtensionMonths: 6"},
 			},
-			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.0", Release: "1.2.x", ReleaseDate: "2019-01-01", EndOfLifeDate: "2020-07-01", Branch: "release-1.2", ExtensionMonths: 6},
+			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.0", Release: "1.2.x", ReleaseDate: "2019-01-01", EndOfLifeDate: "2020-07-01", Branch: "release-1.2", ExtensionMonths: 6, LatestReleaseDate: "2019-01-01"},
 		),
 		simpleCase(
 			"extended on non-first release is ignored",
-			[]github.GQLRelease{
+			[]github.Release{
 				{Name: "1.2.1", Description: "> ExtensionMonths: 6", PublishedAt: d1.Add(time.Hour * 48)},
 				{Name: "1.2.0", Description: "foo", PublishedAt: d1},
 			},
-			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", ReleaseDate: "2020-12-12", EndOfLifeDate: "2021-12-12", Branch: "release-1.2"},
+			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", ReleaseDate: "2020-12-12", EndOfLifeDate: "2021-12-12", Branch: "release-1.2", LatestReleaseDate: "2020-12-14"},
+		),
+		simpleCase(
+			"metadata block",
+			[]github.Release{
+				{Name: "1.2.0", Description: "---\nreleaseDate: 2019-01-01\nlts: true\n---\nintro", PublishedAt: d1},
+				{Name: "1.2.1", Description: "```release-metadata\nsupportTier: extended\n```", PublishedAt: d1.Add(time.Hour * 48)},
+			},
+			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", LTS: true, ReleaseDate: "2019-01-01", EndOfLifeDate: "2021-01-01", Branch: "release-1.2", SupportTier: "extended", LatestReleaseDate: "2020-12-14"},
+		),
+		simpleCase(
+			"end of life and security only from later patches",
+			[]github.Release{
+				{Name: "1.2.0", PublishedAt: d1},
+				{Name: "1.2.1", Description: "> EndOfLife: 2021/06/30\n> SecurityOnly", PublishedAt: d1.Add(time.Hour * 48)},
+				{Name: "1.2.2", Description: "> EndOfLife: 2021/09/30", IsDraft: true},
+			},
+			versionfile.VersionEntry{Edition: "mesh", Version: "1.2.1", Release: "1.2.x", ReleaseDate: "2020-12-12", EndOfLifeDate: "2021-06-30", Branch: "release-1.2", SecurityOnly: true, LatestReleaseDate: "2020-12-14"},
 		),
 	} {
 		t.Run(v.desc, func(t *testing.T) {
-			res, err := versionfile.BuildVersionEntry(v.inEdition, v.inReleaseName, v.inLifetimeMonths, v.inLtsLifetimeMonths, v.inReleases)
+			releases, err := github.VersionParser{}.ParseReleases(v.inReleases, github.UnparsableFail)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := versionfile.BuildVersionEntry(v.inEdition, v.inReleaseName, versionfile.SupportPolicy{LifetimeMonths: v.inLifetimeMonths, LTSLifetimeMonths: v.inLtsLifetimeMonths}, releases, nil)
 			if err != nil {
 				t.Errorf("%+v", err)
 			}
@@ -139,3 +174,151 @@ func TestBuildVersionEntry(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildVersionEntries(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+	releases := []github.Release{
+		{Name: "1.0.0", PublishedAt: day("2020-01-01")},
+		{Name: "1.0.1", PublishedAt: day("2020-02-01")},
+		{Name: "1.1.0", PublishedAt: day("2020-04-01")},
+		{Name: "1.2.0", PublishedAt: day("2020-07-01"), Description: "> EndOfLife: 2020/12/31"},
+		{Name: "1.3.0", IsDraft: true},
+	}
+	parsed, err := github.VersionParser{}.ParseReleases(releases, github.UnparsableFail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		desc     string
+		policy   versionfile.SupportPolicy
+		expected map[string][2]string
+	}{
+		{
+			desc:   "lifetime only",
+			policy: versionfile.SupportPolicy{LifetimeMonths: 12},
+			expected: map[string][2]string{
+				"1.0.x": {"", "2021-01-01"},
+				"1.1.x": {"", "2021-04-01"},
+				"1.2.x": {"", "2020-12-31"},
+				"1.3.x": {"", ""},
+			},
+		},
+		{
+			desc:   "end of life after 2 minors",
+			policy: versionfile.SupportPolicy{LifetimeMonths: 12, EOLAfterMinors: 2},
+			expected: map[string][2]string{
+				"1.0.x": {"", "2020-07-01"},
+				"1.1.x": {"", "2021-04-01"},
+				"1.2.x": {"", "2020-12-31"},
+				"1.3.x": {"", ""},
+			},
+		},
+		{
+			desc:   "active support by months and minors",
+			policy: versionfile.SupportPolicy{LifetimeMonths: 12, ActiveSupportMonths: 4, ActiveSupportMinors: 1},
+			expected: map[string][2]string{
+				"1.0.x": {"2020-04-01", "2021-01-01"},
+				"1.1.x": {"2020-07-01", "2021-04-01"},
+				"1.2.x": {"2020-11-01", "2020-12-31"},
+				"1.3.x": {"", ""},
+			},
+		},
+	} {
+		t.Run(c.desc, func(t *testing.T) {
+			entries, err := versionfile.BuildVersionEntries("kuma", c.policy, append([]github.ParsedRelease{}, parsed...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := map[string][2]string{}
+			var order []string
+			for _, e := range entries {
+				res[e.Release] = [2]string{e.EndOfActiveSupportDate, e.EndOfLifeDate}
+				order = append(order, e.Release)
+			}
+			if !reflect.DeepEqual(res, c.expected) {
+				t.Errorf("got %v expected %v", res, c.expected)
+			}
+			if !reflect.DeepEqual(order, []string{"1.0.x", "1.1.x", "1.2.x", "1.3.x"}) {
+				t.Errorf("unexpected order %v", order)
+			}
+		})
+	}
+}
+
+func TestBuildVersionEntriesEOLAfterMinorsKeepsLifetime(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse(time.DateOnly, s)
+		return d
+	}
+	releases := []github.Release{
+		{Name: "1.0.0", PublishedAt: day("2020-01-01"), Description: "> LTS"},
+		{Name: "1.1.0", PublishedAt: day("2020-03-01"), Description: "> ExtensionMonths: 6"},
+		{Name: "1.2.0", PublishedAt: day("2020-05-01")},
+		{Name: "1.3.0", PublishedAt: day("2020-07-01")},
+		{Name: "1.4.0", PublishedAt: day("2022-07-01")},
+	}
+	parsed, err := github.VersionParser{}.ParseReleases(releases, github.UnparsableFail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := versionfile.BuildVersionEntries("kuma", versionfile.SupportPolicy{LifetimeMonths: 12, LTSLifetimeMonths: 24, EOLAfterMinors: 2}, parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := map[string]string{}
+	for _, e := range entries {
+		res[e.Release] = e.EndOfLifeDate
+	}
+	expected := map[string]string{
+		// LTS and extended versions keep their lifetime when the next minors are released earlier
+		"1.0.x": "2022-01-01",
+		"1.1.x": "2021-09-01",
+		"1.2.x": "2022-07-01",
+		"1.3.x": "2021-07-01",
+		"1.4.x": "2023-07-01",
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("got %v expected %v", res, expected)
+	}
+}
+
+func TestDaysUntilEOL(t *testing.T) {
+	entry := versionfile.VersionEntry{EndOfLifeDate: "2021-01-01"}
+	for at, expected := range map[string]int{
+		"2020-12-01T00:00:00Z": 31,
+		"2020-12-31T23:59:59Z": 1,
+		"2021-01-01T12:00:00Z": 0,
+		"2021-01-11T00:00:00Z": -10,
+	} {
+		d, _ := time.Parse(time.RFC3339, at)
+		if days := entry.DaysUntilEOL(d); days == nil || *days != expected {
+			t.Errorf("at %s expected %d got %v", at, expected, days)
+		}
+	}
+	if days := (versionfile.VersionEntry{}).DaysUntilEOL(time.Now()); days != nil {
+		t.Errorf("expected no days without end of life got %d", *days)
+	}
+}
+
+func TestPhase(t *testing.T) {
+	entry := versionfile.VersionEntry{ReleaseDate: "2020-01-01", EndOfActiveSupportDate: "2020-06-01", EndOfLifeDate: "2021-01-01"}
+	for at, expected := range map[string]versionfile.SupportPhase{
+		"2020-03-01": versionfile.PhaseActive,
+		"2020-06-01": versionfile.PhaseSecurity,
+		"2021-01-01": versionfile.PhaseEOL,
+	} {
+		d, _ := time.Parse(time.DateOnly, at)
+		if phase := entry.Phase(d); phase != expected {
+			t.Errorf("at %s expected %s got %s", at, expected, phase)
+		}
+	}
+	if phase := (versionfile.VersionEntry{Label: "dev"}).Phase(time.Now()); phase != versionfile.PhasePreview {
+		t.Errorf("expected preview got %s", phase)
+	}
+	if phase := (versionfile.VersionEntry{ReleaseDate: "2020-01-01", SecurityOnly: true}).Phase(time.Now()); phase != versionfile.PhaseSecurity {
+		t.Errorf("expected security got %s", phase)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kumahq/ci-tools/cmd/internal/registry"
+)
+
+const (
+	artifactsStartMarker = "<!-- release-tool:artifacts:start -->"
+	artifactsEndMarker   = "<!-- release-tool:artifacts:end -->"
+)
+
+type releaseArtifacts struct {
+	ChartName string
+	ChartURL  string
+	// Images are full references with digest (e.g. kumahq/kuma-cp:2.11.8@sha256:...)
+	Images   []string
+	Binaries []binaryTemplateData
+}
+
+// Section renders the `## Artifacts` section delimited by markers so it can be replaced on re-runs.
+func (a releaseArtifacts) Section() string {
+	sb := &strings.Builder{}
+	sb.WriteString(artifactsStartMarker + "\n## Artifacts\n\n")
+	if a.ChartURL != "" {
+		_, _ = fmt.Fprintf(sb, "### Helm chart\n\n* [%s](%s)\n\n", a.ChartName, a.ChartURL)
+	}
+	if len(a.Images) > 0 {
+		sb.WriteString("### Docker images\n\n")
+		for _, img := range a.Images {
+			_, _ = fmt.Fprintf(sb, "* `%s`\n", img)
+		}
+		sb.WriteString("\n")
+	}
+	if len(a.Binaries) > 0 {
+		sb.WriteString("### Binaries\n\n")
+		for _, b := range a.Binaries {
+			_, _ = fmt.Fprintf(sb, "* [%s](%s)\n", b.Binary, b.URL)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(artifactsEndMarker + "\n")
+	return sb.String()
+}
+
+// upsertArtifactsSection replaces the section between the markers in body.
+// If there's no section yet it's added before the `## Changelog` (so `release changelog` keeps it) or at the end.
+func upsertArtifactsSection(body string, section string) string {
+	if start := strings.Index(body, artifactsStartMarker); start != -1 {
+		if end := strings.Index(body[start:], artifactsEndMarker); end != -1 {
+			rest := strings.TrimPrefix(body[start+end+len(artifactsEndMarker):], "\n")
+			return body[:start] + section + rest
+		}
+	}
+	if idx := strings.Index(body, "## Changelog"); idx != -1 {
+		return body[:idx] + section + "\n" + body[idx:]
+	}
+	if body != "" && !strings.HasSuffix(body, "\n") {
+		body += "\n"
+	}
+	if body != "" {
+		body += "\n"
+	}
+	return body + section
+}
+
+// imageReferences resolves the digest of each of the images of the release.
+func imageReferences(ctx context.Context, regClient *registry.Client, tag string) ([]string, error) {
+	var out []string
+	for _, i := range config.images {
+		repo := fmt.Sprintf("%s/%s", config.dockerRepo, i)
+		digest, err := regClient.Resolve(ctx, repo, tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed with image: %s:%s %w", repo, tag, err)
+		}
+		out = append(out, fmt.Sprintf("%s:%s@%s", repo, tag, digest))
+	}
+	return out, nil
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -21,6 +20,13 @@ const (
 	FormatJson     OutFormat = "json"
 )
 
+// changelogEntry is the json output of changelog.md for each release.
+type changelogEntry struct {
+	Name       string `json:"name"`
+	ReleasedOn string `json:"releasedOn"`
+	Changelog  string `json:"changelog"`
+}
+
 var autoChangelog = &cobra.Command{
 	Use:   "changelog.md",
 	Short: "Recreate the changelog.md using the changelog in each github release",
@@ -28,15 +34,26 @@ var autoChangelog = &cobra.Command{
 	We use whatever is after '## Changelog' to build the changelog
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		gqlClient, err := github.NewGQLClient(config.useGHAuth)
+		forge, err := newForge()
 		if err != nil {
 			return err
 		}
 
-		res, err := gqlClient.ReleaseGraphQL(config.repo)
+		releases, err := forge.Releases(config.repo)
+		if err != nil {
+			return err
+		}
+		res, err := parseReleases(releases)
 		if err != nil {
 			return err
 		}
+		// The release date of the metadata in the description wins over the one of the forge
+		releasedOn := map[string]time.Time{}
+		for _, r := range res {
+			if releasedOn[r.Name], err = r.ReleaseDate(); err != nil {
+				return err
+			}
+		}
 		sort.SliceStable(res, func(i, j int) bool {
 			if res[i].IsLatest {
 				return true
@@ -45,14 +62,15 @@ var autoChangelog = &cobra.Command{
 				return false
 			}
 			// If they are released roughly at the same time we should sort them by semver order
-			if time.Time.Equal(res[i].PublishedAt.Truncate(time.Hour*24), res[j].PublishedAt.Truncate(time.Hour*24)) {
-				return !res[i].SemVer().LessThan(res[j].SemVer())
+			di, dj := releasedOn[res[i].Name], releasedOn[res[j].Name]
+			if time.Time.Equal(di.Truncate(time.Hour*24), dj.Truncate(time.Hour*24)) {
+				return !res[i].Version.LessThan(res[j].Version)
 			}
-			return res[i].PublishedAt.After(res[j].PublishedAt)
+			return di.After(dj)
 		})
-		childReleases := map[string]github.GQLRelease{}
+		childReleases := map[string]github.Release{}
 		if config.childRepo != "" {
-			childResources, err := gqlClient.ReleaseGraphQL(config.childRepo)
+			childResources, err := forge.Releases(config.childRepo)
 			if err != nil {
 				return err
 			}
@@ -60,7 +78,8 @@ var autoChangelog = &cobra.Command{
 				childReleases[release.Name] = release
 			}
 		}
-		_, _ = cmd.OutOrStdout().Write([]byte("# Changelog\n<!-- Autogenerated with (github.com/kumahq/ci-tools) release-tool changelog.md -->\n"))
+		var entries []changelogEntry
+		result.printf("# Changelog\n<!-- Autogenerated with (github.com/kumahq/ci-tools) release-tool changelog.md -->\n")
 		for _, release := range res {
 			if !release.IsReleased() { // If the release is not an actual release don't add in changelog.md
 				continue
@@ -71,13 +90,16 @@ var autoChangelog = &cobra.Command{
 					changelog += fmt.Sprintf("\n### Includes [%s@%s](https://github.com/%s/releases/tag/%s) changelog", config.childRepo, childRelease.Name, config.childRepo, childRelease.Name)
 					changelog += strings.SplitN(childRelease.Description, "## Changelog", 2)[1]
 				}
-				_, _ = fmt.Fprintf(cmd.OutOrStdout(), `
+				result.printf(`
 ## %s
 > Released on %s%s
-`, release.Name, release.PublishedAt.Format("2006/01/02"), changelog)
+`, release.Name, releasedOn[release.Name].Format("2006/01/02"), changelog)
+				entries = append(entries, changelogEntry{Name: release.Name, ReleasedOn: releasedOn[release.Name].Format(time.DateOnly), Changelog: changelog})
+				result.Found = append(result.Found, release.Name)
 			}
 
 		}
+		result.Data = entries
 		return nil
 	},
 }
@@ -99,31 +121,34 @@ It will then output a changelog with all PRs with the same changelog grouped tog
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if config.fromTag == "" {
-			return errors.New("you must set either --from-tag")
+			return usageErrorf("you must set either --from-tag")
 		}
 
-		gqlClient, err := github.NewGQLClient(config.useGHAuth)
+		forge, err := newForge()
 		if err != nil {
 			return err
 		}
 
-		fromCommit, err := gqlClient.CommitByRef(config.repo, NormalizeVersionTagWithWarning(config.fromTag))
+		fromCommit, err := tagCommit(forge, config.repo, NormalizeVersionTagWithWarning(config.fromTag))
 		if err != nil {
 			return err
 		}
-		out, err := getChangelog(gqlClient, config.repo, config.branch, fromCommit)
+		out, err := getChangelog(forge, config.repo, config.branch, fromCommit)
 		if err != nil {
 			return err
 		}
-		switch OutFormat(config.format) {
-		case FormatMarkdown:
+		result.Data = out
+		switch {
+		case result.isJson():
+			return nil
+		case OutFormat(config.format) == FormatMarkdown:
 			for _, v := range out {
 				_, err = fmt.Fprintf(cmd.OutOrStdout(), "* %s\n", v)
 				if err != nil {
 					return err
 				}
 			}
-		case FormatJson:
+		case OutFormat(config.format) == FormatJson:
 			e := json.NewEncoder(cmd.OutOrStdout())
 			e.SetIndent("", "  ")
 			return e.Encode(out)
@@ -132,26 +157,20 @@ It will then output a changelog with all PRs with the same changelog grouped tog
 	},
 }
 
-func getChangelog(gqlClient *github.GQLClient, repo string, branch string, fromCommit string) (changeloggenerator.Changelog, error) {
-	res, err := gqlClient.HistoryGraphQl(repo, branch, fromCommit)
+func getChangelog(history github.HistoryReader, repo string, branch string, fromCommit string) (changeloggenerator.Changelog, error) {
+	res, err := history.History(repo, branch, fromCommit)
 	if err != nil {
 		return nil, err
 	}
 	var commitInfos []changeloggenerator.CommitInfo
 	for _, commit := range res {
-		var pr *github.GQLPRNode
-		for _, prNode := range commit.AssociatedPullRequests.Nodes {
-			if prNode.Merged && prNode.MergeCommit.Oid == commit.Oid {
-				pr = &prNode
-				break
-			}
-		}
+		pr := commit.PullRequest
 		if pr == nil {
 			continue
 		}
 		ci := changeloggenerator.CommitInfo{
-			Author:        pr.Author.Login,
-			Sha:           commit.Oid,
+			Author:        pr.Author,
+			Sha:           commit.Sha,
 			PrNumber:      pr.Number,
 			PrTitle:       pr.Title,
 			PrBody:        pr.Body,
@@ -162,11 +181,22 @@ func getChangelog(gqlClient *github.GQLClient, repo string, branch string, fromC
 	return changeloggenerator.New(config.repo, commitInfos)
 }
 
+// tagCommit returns the commit tag points to, it fails if the tag doesn't exist as History would walk the whole branch.
+func tagCommit(forge github.RefResolver, repo, tag string) (string, error) {
+	sha, err := forge.CommitByRef(repo, tag)
+	if err != nil {
+		return "", err
+	}
+	if sha == "" {
+		return "", fmt.Errorf("couldn't find tag %s in %s: %w", tag, repo, github.ErrNotFound)
+	}
+	return sha, nil
+}
+
 func init() {
 	versionChangelog.Flags().StringVar(&config.branch, "branch", "master", "The branch to look for the start on")
 	versionChangelog.Flags().StringVar(&config.fromTag, "from-tag", "", "If set only show commits after this tag (must be on the same branch)")
 	versionChangelog.Flags().StringVar(&config.format, "format", string(FormatMarkdown), fmt.Sprintf("The output format (%s, %s)", FormatJson, FormatMarkdown))
-	versionChangelog.Flags().StringVar(&config.repo, "repo", "kumahq/kuma", "The repository to query")
-	autoChangelog.Flags().StringVar(&config.repo, "repo", "kumahq/kuma", "The repository to query")
 	autoChangelog.Flags().StringVar(&config.childRepo, "childRepo", "", "The child repository to query")
+	addReleaseParsingFlags(autoChangelog.Flags())
 }
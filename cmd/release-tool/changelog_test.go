@@ -0,0 +1,55 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kumahq/ci-tools/cmd/internal/github"
+	"github.com/kumahq/ci-tools/cmd/internal/githubfake"
+)
+
+type staticHistory []github.Commit
+
+func (h staticHistory) History(repo, branch, until string) ([]github.Commit, error) {
+	return h, nil
+}
+
+func TestGetChangelog(t *testing.T) {
+	history := staticHistory{
+		{Sha: "c3", Message: "feat: new thing (#3)", PullRequest: &github.PullRequest{Number: 3, Title: "feat: new thing", Author: "alice"}},
+		{Sha: "c2", Message: "direct push"},
+		{Sha: "c1", Message: "fix: same thing (#1)", PullRequest: &github.PullRequest{Number: 1, Title: "fix: bug", Body: "> Changelog: feat: new thing", Author: "bob"}},
+	}
+	config.repo = "kumahq/kuma"
+	changelog, err := getChangelog(history, "kumahq/kuma", "master", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, c := range changelog {
+		lines = append(lines, c.String())
+	}
+	expected := []string{"feat: new thing [#1](https://github.com/kumahq/kuma/pull/1) [#3](https://github.com/kumahq/kuma/pull/3) @alice,@bob"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("got %q expected %q", lines, expected)
+	}
+}
+
+func TestChangelogMissingTag(t *testing.T) {
+	tests := []struct {
+		desc string
+		args []string
+	}{
+		{desc: "version-changelog", args: []string{"version-changelog", "--branch", "release-2.11", "--from-tag", "2.11.9"}},
+		{desc: "release changelog", args: []string{"release", "changelog", "--release", "2.10.2", "--dry-run"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			fake := githubfake.Load(t, filepath.Join("testdata", "github.yaml"))
+			if _, err := runCommand(t, fake, tt.args...); exitCode(err) != ExitNotFound {
+				t.Errorf("expected a not found error instead of reading the whole branch got %v", err)
+			}
+		})
+	}
+}
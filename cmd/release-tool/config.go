@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultConfigFile = "release-tool.yaml"
+	envPrefix         = "RELEASE_TOOL_"
+)
+
+// configFile is the content of `release-tool.yaml`, settings are keyed by flag name:
+//
+//	defaultProfile: kuma
+//	defaults:
+//	  lifetime-months: 12
+//	profiles:
+//	  kuma:
+//	    repo: kumahq/kuma
+//	    docker-repo: kumahq
+//	    images: [kuma-cp, kuma-dp, kumactl]
+//	  kong-mesh:
+//	    repo: Kong/kong-mesh
+//	    edition: mesh
+type configFile struct {
+	DefaultProfile string                    `yaml:"defaultProfile"`
+	Defaults       map[string]any            `yaml:"defaults"`
+	Profiles       map[string]map[string]any `yaml:"profiles"`
+}
+
+// settings returns the settings of the profile merged on top of the defaults.
+func (c configFile) settings(profile string) (map[string]string, error) {
+	out := map[string]string{}
+	for k, v := range c.Defaults {
+		out[k] = settingString(v)
+	}
+	if profile == "" {
+		profile = c.DefaultProfile
+	}
+	if profile == "" && len(c.Profiles) == 1 {
+		for p := range c.Profiles {
+			profile = p
+		}
+	}
+	if profile == "" {
+		return out, nil
+	}
+	p, found := c.Profiles[profile]
+	if !found {
+		var names []string
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown profile %q (available: %s)", profile, strings.Join(names, ", "))
+	}
+	for k, v := range p {
+		out[k] = settingString(v)
+	}
+	return out, nil
+}
+
+func settingString(v any) string {
+	if l, ok := v.([]any); ok {
+		var items []string
+		for _, i := range l {
+			items = append(items, fmt.Sprint(i))
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v)
+}
+
+// findConfigFile looks for `release-tool.yaml` in the current directory and its parents up to the git root.
+func findConfigFile() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		p := filepath.Join(dir, defaultConfigFile)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func envName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// loadConfig sets every flag of cmd that wasn't passed on the command line from
+// its `RELEASE_TOOL_<FLAG>` env var or else from the selected profile of the config file.
+func loadConfig(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	// The config file and profile can't come from the config file itself
+	for _, name := range []string{"config", "profile"} {
+		f := flags.Lookup(name)
+		if f == nil || f.Changed {
+			continue
+		}
+		if v, ok := os.LookupEnv(envName(name)); ok {
+			if err := f.Value.Set(v); err != nil {
+				return fmt.Errorf("invalid %s: %w", envName(name), err)
+			}
+		}
+	}
+
+	path := config.configFile
+	if path == "" {
+		path = findConfigFile()
+	}
+	settings := map[string]string{}
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var file configFile
+		if err := yaml.Unmarshal(b, &file); err != nil {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+		settings, err = file.settings(config.profile)
+		if err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+		if err := validateSettings(cmd.Root(), settings); err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	} else if config.profile != "" {
+		return errors.New("--profile is set but no config file was found")
+	}
+
+	var errs []error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Changed || f.Name == "config" || f.Name == "profile" {
+			return
+		}
+		v, ok := os.LookupEnv(envName(f.Name))
+		source := envName(f.Name)
+		if !ok {
+			v, ok = settings[f.Name]
+			source = fmt.Sprintf("%s in %s", f.Name, path)
+		}
+		if !ok {
+			return
+		}
+		if err := f.Value.Set(v); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", source, err))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// validateSettings fails on settings that aren't a flag of any command to catch typos.
+func validateSettings(root *cobra.Command, settings map[string]string) error {
+	known := map[string]bool{}
+	var visit func(c *cobra.Command)
+	visit = func(c *cobra.Command) {
+		c.Flags().VisitAll(func(f *pflag.Flag) { known[f.Name] = true })
+		c.PersistentFlags().VisitAll(func(f *pflag.Flag) { known[f.Name] = true })
+		for _, child := range c.Commands() {
+			visit(child)
+		}
+	}
+	visit(root)
+	var unknown []string
+	for k := range settings {
+		if !known[k] || k == "config" || k == "profile" {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown settings: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
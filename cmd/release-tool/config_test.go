@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, defaultConfigFile)
+	err := os.WriteFile(configPath, []byte(`
+defaultProfile: kuma
+defaults:
+  lifetime-months: 12
+profiles:
+  kuma:
+    repo: kumahq/kuma
+    docker-repo: kumahq
+    images: [kuma-cp, kuma-dp]
+  kong-mesh:
+    repo: Kong/kong-mesh
+    docker-repo: kong
+    lifetime-months: 18
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newCmd := func() *cobra.Command {
+		config = Config{}
+		cmd := &cobra.Command{Use: "test", RunE: func(cmd *cobra.Command, args []string) error { return nil }}
+		cmd.Flags().StringVar(&config.configFile, "config", "", "")
+		cmd.Flags().StringVar(&config.profile, "profile", "", "")
+		cmd.Flags().StringVar(&config.repo, "repo", "default/repo", "")
+		cmd.Flags().StringVar(&config.dockerRepo, "docker-repo", "", "")
+		cmd.Flags().StringSliceVar(&config.images, "images", nil, "")
+		cmd.Flags().IntVar(&config.lifetimeMonths, "lifetime-months", 6, "")
+		return cmd
+	}
+	load := func(t *testing.T, args ...string) error {
+		t.Helper()
+		cmd := newCmd()
+		if err := cmd.ParseFlags(append([]string{"--config", configPath}, args...)); err != nil {
+			t.Fatal(err)
+		}
+		return loadConfig(cmd)
+	}
+
+	t.Run("default profile", func(t *testing.T) {
+		if err := load(t); err != nil {
+			t.Fatal(err)
+		}
+		if config.repo != "kumahq/kuma" || config.dockerRepo != "kumahq" || config.lifetimeMonths != 12 || !reflect.DeepEqual(config.images, []string{"kuma-cp", "kuma-dp"}) {
+			t.Errorf("unexpected config %+v", config)
+		}
+	})
+	t.Run("selected profile", func(t *testing.T) {
+		if err := load(t, "--profile", "kong-mesh"); err != nil {
+			t.Fatal(err)
+		}
+		if config.repo != "Kong/kong-mesh" || config.dockerRepo != "kong" || config.lifetimeMonths != 18 || config.images != nil {
+			t.Errorf("unexpected config %+v", config)
+		}
+	})
+	t.Run("env overrides config and flags override env", func(t *testing.T) {
+		t.Setenv("RELEASE_TOOL_DOCKER_REPO", "from-env")
+		t.Setenv("RELEASE_TOOL_PROFILE", "kong-mesh")
+		if err := load(t, "--repo", "from/flag"); err != nil {
+			t.Fatal(err)
+		}
+		if config.repo != "from/flag" || config.dockerRepo != "from-env" || config.lifetimeMonths != 18 {
+			t.Errorf("unexpected config %+v", config)
+		}
+	})
+	t.Run("unknown profile", func(t *testing.T) {
+		if err := load(t, "--profile", "nope"); err == nil || !strings.Contains(err.Error(), "available: kong-mesh, kuma") {
+			t.Errorf("expected unknown profile error got %v", err)
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/spf13/cobra"
+
+	"github.com/kumahq/ci-tools/cmd/internal/github"
+	"github.com/kumahq/ci-tools/cmd/internal/versionfile"
+)
+
+var eolReportFlags struct {
+	withinDays       int
+	failWithinDays   int
+	failOnEOLCommits bool
+}
+
+type eolReportEntry struct {
+	Branch        string `json:"branch"`
+	Release       string `json:"release"`
+	Latest        string `json:"latest"`
+	EndOfLifeDate string `json:"endOfLifeDate,omitempty"`
+	// DaysUntilEOL is negative for versions already EOL
+	DaysUntilEOL *int `json:"daysUntilEOL,omitempty"`
+	// Commits is the number of commits on the branch since the latest release
+	Commits int `json:"commits,omitempty"`
+}
+
+type eolReportData struct {
+	AsOf           string           `json:"asOf"`
+	ReachingEOL    []eolReportEntry `json:"reachingEOL"`
+	EOLWithCommits []eolReportEntry `json:"eolWithCommits"`
+	LTS            []eolReportEntry `json:"lts"`
+}
+
+var eolReportCmd = &cobra.Command{
+	Use:   "eol-report",
+	Short: "Report the versions reaching their end of life soon, EOL branches still getting commits and LTS versions",
+	Long: `Report the versions reaching their end of life within --within-days, the branches of EOL versions with commits
+since their latest release and the LTS versions not EOL. It's meant to run as a scheduled check, e.g.:
+
+	release-tool version-file eol-report --within-days 60 --fail-within-days 14 --fail-on-eol-commits
+
+The command fails when a version reaches its end of life within --fail-within-days or with --fail-on-eol-commits
+when an EOL branch has commits not released. The flags of version-file (e.g. --as-of or --lifetime-months) apply.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if eolReportFlags.withinDays < 0 || eolReportFlags.failWithinDays < 0 {
+			return usageErrorf("--within-days and --fail-within-days can't be negative")
+		}
+		at, err := versionFileAsOf()
+		if err != nil {
+			return err
+		}
+		forge, err := newForge()
+		if err != nil {
+			return err
+		}
+		entries, err := buildVersionFile(forge, defaultEditionSource())
+		if err != nil {
+			return err
+		}
+
+		data := eolReportData{AsOf: at.Format(time.DateOnly), ReachingEOL: []eolReportEntry{}, EOLWithCommits: []eolReportEntry{}, LTS: []eolReportEntry{}}
+		var errs error
+		for _, v := range entries {
+			entry := eolReportEntry{Branch: v.Branch, Release: v.Release, Latest: v.Version, EndOfLifeDate: v.EndOfLifeDate, DaysUntilEOL: v.DaysUntilEOL(at)}
+			switch v.Phase(at) {
+			case versionfile.PhasePreview:
+				continue
+			case versionfile.PhaseEOL:
+				commits, err := commitsSinceRelease(cmd.Context(), forge, config.repo, v)
+				if err != nil {
+					return err
+				}
+				if commits == 0 {
+					continue
+				}
+				entry.Commits = commits
+				data.EOLWithCommits = append(data.EOLWithCommits, entry)
+				if eolReportFlags.failOnEOLCommits {
+					errs = multierror.Append(errs, fmt.Errorf("branch %s is end of life since %s but has %d commits since %s", v.Branch, v.EndOfLifeDate, commits, v.Version))
+				}
+				continue
+			}
+			if entry.DaysUntilEOL != nil && *entry.DaysUntilEOL <= eolReportFlags.withinDays {
+				data.ReachingEOL = append(data.ReachingEOL, entry)
+			}
+			if eolReportFlags.failWithinDays > 0 && entry.DaysUntilEOL != nil && *entry.DaysUntilEOL <= eolReportFlags.failWithinDays {
+				errs = multierror.Append(errs, fmt.Errorf("version %s reaches its end of life on %s", v.Release, v.EndOfLifeDate))
+			}
+			if v.LTS {
+				data.LTS = append(data.LTS, entry)
+			}
+		}
+
+		result.Data = data
+		result.printf("End of life report as of %s\n", data.AsOf)
+		result.printf("\nReaching end of life within %d days:\n", eolReportFlags.withinDays)
+		for _, e := range data.ReachingEOL {
+			result.found(e.Branch, "  %s (%s, latest %s) on %s in %d days\n", e.Release, e.Branch, e.Latest, e.EndOfLifeDate, *e.DaysUntilEOL)
+		}
+		result.printf("\nEnd of life with commits since their latest release:\n")
+		for _, e := range data.EOLWithCommits {
+			result.found(e.Branch, "  %s (%s) since %s, %d commits since %s\n", e.Release, e.Branch, e.EndOfLifeDate, e.Commits, e.Latest)
+		}
+		result.printf("\nLTS:\n")
+		for _, e := range data.LTS {
+			result.printf("  %s (%s, latest %s) until %s\n", e.Release, e.Branch, e.Latest, e.EndOfLifeDate)
+		}
+		return errs
+	},
+}
+
+// commitsSinceRelease returns the number of commits on the branch of v after the tag of its latest release, 0 if the tag doesn't exist.
+// It fails if the tag isn't on the branch as the count would be meaningless.
+func commitsSinceRelease(ctx context.Context, forge github.Forge, repo string, v versionfile.VersionEntry) (int, error) {
+	tag := NormalizeVersionTag(v.Version)
+	sha, err := forge.CommitByRef(repo, tag)
+	if err != nil {
+		return 0, err
+	}
+	if sha == "" {
+		slog.Warn("skipping commits check, tag not found", "repo", repo, "tag", tag)
+		return 0, nil
+	}
+	comparison, err := forge.Compare(ctx, repo, sha, v.Branch)
+	if err != nil {
+		return 0, err
+	}
+	if comparison.MergeBase != sha {
+		return 0, fmt.Errorf("tag %s isn't on branch %s of %s", tag, v.Branch, repo)
+	}
+	return comparison.AheadBy, nil
+}
+
+func init() {
+	eolReportCmd.Flags().IntVar(&eolReportFlags.withinDays, "within-days", 30, "Report the versions reaching their end of life within this number of days")
+	eolReportCmd.Flags().IntVar(&eolReportFlags.failWithinDays, "fail-within-days", 0, "Fail if a version reaches its end of life within this number of days (0 disables it)")
+	eolReportCmd.Flags().BoolVar(&eolReportFlags.failOnEOLCommits, "fail-on-eol-commits", false, "Fail if the branch of an end of life version has commits since its latest release")
+
+	versionFile.AddCommand(eolReportCmd)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/kumahq/ci-tools/cmd/internal/github"
+)
+
+// Exit codes of release-tool, they are stable so CI can decide whether to retry, alert or fail.
+const (
+	ExitOK = 0
+	// ExitFailure is any error that doesn't fit in another class.
+	ExitFailure = 1
+	// ExitUsage is an invalid invocation: missing or invalid flags, config file or manifest.
+	ExitUsage = 2
+	// ExitAuth is a missing GitHub token or a token that was refused.
+	ExitAuth = 3
+	// ExitNotFound is a GitHub resource (repository, release, tag...) that doesn't exist.
+	ExitNotFound = 4
+	// ExitArtifactMissing is a release artifact that is missing or fails verification.
+	ExitArtifactMissing = 5
+	// ExitConflict is a state preventing the change (e.g. the release is already published).
+	ExitConflict = 6
+	// ExitRateLimit is a GitHub rate limit, retry later.
+	ExitRateLimit = 7
+	// ExitTransient is a GitHub server or network error, retry.
+	ExitTransient = 8
+)
+
+var (
+	errUsage           = errors.New("usage error")
+	errArtifactMissing = errors.New("artifact missing")
+)
+
+// classError gives a class to err without changing its message.
+type classError struct {
+	class error
+	err   error
+}
+
+func (e *classError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classError) Unwrap() []error {
+	return []error{e.class, e.err}
+}
+
+func usageErrorf(format string, a ...any) error {
+	return &classError{class: errUsage, err: fmt.Errorf(format, a...)}
+}
+
+func usageError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classError{class: errUsage, err: err}
+}
+
+func artifactMissingError(err error) error {
+	return &classError{class: errArtifactMissing, err: err}
+}
+
+func conflictErrorf(format string, a ...any) error {
+	return &classError{class: github.ErrConflict, err: fmt.Errorf(format, a...)}
+}
+
+// exitCode maps an error to its exit code, when several errors are aggregated the one needing the most attention wins.
+func exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errUsage):
+		return ExitUsage
+	case errors.Is(err, github.ErrAuth), errors.Is(err, github.ErrGitHubTokenNotFound), errors.Is(err, github.ErrGHNotInstalled),
+		errors.Is(err, github.ErrGHAuthFailed), errors.Is(err, github.ErrGHAuthEmptyToken):
+		return ExitAuth
+	case errors.Is(err, github.ErrRateLimit):
+		return ExitRateLimit
+	case errors.Is(err, github.ErrServer), errors.As(err, &netErr):
+		return ExitTransient
+	case errors.Is(err, github.ErrConflict):
+		return ExitConflict
+	case errors.Is(err, github.ErrNotFound):
+		return ExitNotFound
+	case errors.Is(err, errArtifactMissing):
+		return ExitArtifactMissing
+	}
+	return ExitFailure
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/kumahq/ci-tools/cmd/internal/github"
+)
+
+func TestExitCode(t *testing.T) {
+	var merr *multierror.Error
+	merr = multierror.Append(merr, artifactMissingError(errors.New("couldn't get foo: 404")))
+	merr = multierror.Append(merr, &github.APIError{StatusCode: 502, Class: github.ErrServer})
+
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "nil", err: nil, expected: ExitOK},
+		{name: "generic", err: errors.New("boom"), expected: ExitFailure},
+		{name: "usage", err: usageErrorf("must set --release"), expected: ExitUsage},
+		{name: "missing token", err: github.ErrGitHubTokenNotFound, expected: ExitAuth},
+		{name: "unauthorized", err: fmt.Errorf("wrapped: %w", &github.APIError{StatusCode: 401, Class: github.ErrAuth}), expected: ExitAuth},
+		{name: "not found", err: &github.APIError{StatusCode: 404, Class: github.ErrNotFound}, expected: ExitNotFound},
+		{name: "rate limit", err: &github.APIError{StatusCode: 403, Class: github.ErrRateLimit}, expected: ExitRateLimit},
+		{name: "already published", err: conflictErrorf("release 2.11.8 is already published"), expected: ExitConflict},
+		{name: "artifact missing", err: artifactMissingError(errors.New("couldn't get foo: 404")), expected: ExitArtifactMissing},
+		{name: "network", err: &net.OpError{Op: "dial", Err: errors.New("refused")}, expected: ExitTransient},
+		{name: "aggregated picks the retryable one", err: merr.ErrorOrNil(), expected: ExitTransient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.expected {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.expected)
+			}
+		})
+	}
+	if msg := usageErrorf("must set --%s", "release").Error(); msg != "must set --release" {
+		t.Errorf("classified errors must keep their message got %q", msg)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/kumahq/ci-tools/cmd/internal/github"
+	"github.com/kumahq/ci-tools/cmd/internal/githubfake"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+var wrapCommands sync.Once
+
+// runCommand runs the release-tool with args against fake and returns what it wrote on stdout.
+func runCommand(t *testing.T, fake *githubfake.Server, args ...string) (string, error) {
+	t.Helper()
+	wrapCommands.Do(func() {
+		withResult(rootCmd)
+		rootCmd.SilenceErrors = true
+		rootCmd.SilenceUsage = true
+	})
+	resetFlags(rootCmd)
+	prev := newForge
+	newForge = func() (github.Forge, error) {
+		return fake.GQLClient()
+	}
+	buf := &bytes.Buffer{}
+	rootCmd.SetOut(buf)
+	rootCmd.SetArgs(args)
+	t.Cleanup(func() {
+		newForge = prev
+		rootCmd.SetOut(nil)
+		rootCmd.SetArgs(nil)
+	})
+	_, err := rootCmd.ExecuteC()
+	return buf.String(), err
+}
+
+// resetFlags puts back the default value of all flags as cobra commands are reused across tests.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			var def []string
+			if v := strings.Trim(f.DefValue, "[]"); v != "" {
+				def = strings.Split(v, ",")
+			}
+			_ = s.Replace(def)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, c := range cmd.Commands() {
+		resetFlags(c)
+	}
+}
+
+func assertGolden(t *testing.T, name string, actual string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(actual), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if actual != string(expected) {
+		t.Errorf("output doesn't match %s (run with -update to update it)\ngot:\n%s\nexpected:\n%s", path, actual, expected)
+	}
+}
+
+func TestCommandsGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		golden string
+	}{
+		{
+			name:   "release changelog dry run",
+			args:   []string{"release", "changelog", "--release", "2.11.1", "--dry-run"},
+			golden: "release-changelog-dry-run.golden",
+		},
+		{
+			name:   "release changelog dry run of a minor falls back to the merge-base of release branches",
+			args:   []string{"release", "changelog", "--release", "v2.12.0", "--dry-run"},
+			golden: "release-changelog-minor-dry-run.golden",
+		},
+		{
+			name:   "version-changelog",
+			args:   []string{"version-changelog", "--branch", "release-2.11", "--from-tag", "v2.11.0"},
+			golden: "version-changelog.golden",
+		},
+		{
+			name:   "changelog.md",
+			args:   []string{"changelog.md"},
+			golden: "changelog.md.golden",
+		},
+		{
+			name:   "changelog.md json output",
+			args:   []string{"changelog.md", "--output", "json"},
+			golden: "changelog.md.json.golden",
+		},
+		{
+			name:   "version-file",
+			args:   []string{"version-file"},
+			golden: "version-file.golden",
+		},
+		{
+			name:   "version-file with min version",
+			args:   []string{"version-file", "--min-version", "2.11.0", "--edition", "kong-mesh"},
+			golden: "version-file-min-version.golden",
+		},
+		{
+			name:   "version-file with support phases",
+			args:   []string{"version-file", "--active-support-months", "6", "--eol-after-minors", "1"},
+			golden: "version-file-phases.golden",
+		},
+		{
+			name:   "version-file endoflife.date format",
+			args:   []string{"version-file", "--format", "endoflife", "--active-support-months", "6"},
+			golden: "version-file-endoflife.golden",
+		},
+		{
+			name:   "version-file iCalendar format",
+			args:   []string{"version-file", "--format", "ics"},
+			golden: "version-file.ics.golden",
+		},
+		{
+			name:   "version-file active branches as of a date",
+			args:   []string{"version-file", "--active-branches", "--as-of", "2026-10-18"},
+			golden: "version-file-active-branches.golden",
+		},
+		{
+			name:   "version-file GitHub Actions matrix",
+			args:   []string{"version-file", "--format", "gha-matrix", "--as-of", "2026-10-18"},
+			golden: "version-file-gha-matrix.golden",
+		},
+		{
+			name:   "version-file eol-report",
+			args:   []string{"version-file", "eol-report", "--as-of", "2027-06-01"},
+			golden: "version-file-eol-report.golden",
+		},
+		{
+			name:   "version-file eol-report with commits on an end of life branch",
+			args:   []string{"version-file", "eol-report", "--as-of", "2027-07-01", "--output", "json"},
+			golden: "version-file-eol-report.json.golden",
+		},
+		{
+			name: "version-file merging editions",
+			args: []string{
+				"version-file", "--min-version", "2.10.0",
+				"--edition-source", "edition=kuma,repo=kumahq/kuma",
+				"--edition-source", "edition=kong-mesh,repo=Kong/kong-mesh,lts-lifetime-months=36,based-on=kuma",
+			},
+			golden: "version-file-editions.golden",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := githubfake.Load(t, filepath.Join("testdata", "github.yaml"))
+			out, err := runCommand(t, fake, tt.args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertGolden(t, tt.golden, out)
+		})
+	}
+}
+
+func TestReleaseChangelogUpsert(t *testing.T) {
+	t.Run("creates a draft release", func(t *testing.T) {
+		fake := githubfake.Load(t, filepath.Join("testdata", "github.yaml"))
+		if _, err := runCommand(t, fake, "release", "changelog", "--release", "2.11.1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		release := fake.Release("kumahq/kuma", "2.11.1")
+		if release == nil {
+			t.Fatal("release 2.11.1 wasn't created")
+		}
+		if !release.Draft || release.Tag != "2.11.1" {
+			t.Errorf("expected a draft with tag 2.11.1 got draft=%v tag=%s", release.Draft, release.Tag)
+		}
+		assertGolden(t, "release-changelog-create.golden", release.Body)
+	})
+	t.Run("updates the changelog of a draft and keeps its header", func(t *testing.T) {
+		fake := githubfake.Load(t, filepath.Join("testdata", "github.yaml"))
+		if _, err := runCommand(t, fake, "release", "changelog", "--release", "2.12.0"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		release := fake.Release("kumahq/kuma", "2.12.0")
+		if !release.Draft {
+			t.Error("expected the release to stay a draft")
+		}
+		assertGolden(t, "release-changelog-update.golden", release.Body)
+	})
+	t.Run("refuses to update a published release", func(t *testing.T) {
+		fake := githubfake.Load(t, filepath.Join("testdata", "github.yaml"))
+		before := fake.Release("kumahq/kuma", "2.11.0").Body
+		_, err := runCommand(t, fake, "release", "changelog", "--release", "2.11.0")
+		if exitCode(err) != ExitConflict {
+			t.Errorf("expected a conflict got %v", err)
+		}
+		if after := fake.Release("kumahq/kuma", "2.11.0").Body; after != before {
+			t.Errorf("published release was modified:\n%s", after)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJson = "json"
+)
+
+// newLogger builds the logger for diagnostics, stdout is reserved for command results so it's always given stderr.
+func newLogger(w io.Writer, level string, format string, verbose bool) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, usageErrorf("invalid --log-level %q (must be debug, info, warn or error)", level)
+	}
+	if verbose {
+		lvl = slog.LevelDebug
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case logFormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case logFormatJson:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, usageErrorf("invalid --log-format %q (must be %s or %s)", format, logFormatText, logFormatJson)
+	}
+}
+
+// initCommand loads the configuration and sets up logging, it must run before any command.
+func initCommand(cmd *cobra.Command) error {
+	if err := loadConfig(cmd); err != nil {
+		return usageError(err)
+	}
+	logger, err := newLogger(os.Stderr, config.logLevel, config.logFormat, config.verbose)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
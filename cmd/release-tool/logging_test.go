@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger, err := newLogger(buf, "warn", logFormatJson, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("dropped")
+	logger.Warn("kept", "tag", "2.11.8")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 log line got %q", buf.String())
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["msg"] != "kept" || entry["tag"] != "2.11.8" || entry["level"] != "WARN" {
+		t.Errorf("unexpected entry %v", entry)
+	}
+
+	buf.Reset()
+	logger, err = newLogger(buf, "error", logFormatText, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("verbose wins")
+	if !strings.Contains(buf.String(), "level=DEBUG msg=\"verbose wins\"") {
+		t.Errorf("expected a debug text log got %q", buf.String())
+	}
+
+	if _, err := newLogger(buf, "loud", logFormatText, false); exitCode(err) != ExitUsage {
+		t.Errorf("expected a usage error got %v", err)
+	}
+	if _, err := newLogger(buf, "info", "xml", false); exitCode(err) != ExitUsage {
+		t.Errorf("expected a usage error got %v", err)
+	}
+}
@@ -1,15 +1,30 @@
 package main
 
 import (
-	"errors"
 	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/kumahq/ci-tools/cmd/internal/gitea"
+	"github.com/kumahq/ci-tools/cmd/internal/github"
+	"github.com/kumahq/ci-tools/cmd/internal/gitlab"
 )
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&config.repo, "repo", "kumahq/kuma", "The repository to query")
+	rootCmd.PersistentFlags().StringVar(&config.configFile, "config", "", fmt.Sprintf("The config file with per product profiles (defaults to %s in the current directory or its parents up to the git root)", defaultConfigFile))
+	rootCmd.PersistentFlags().StringVar(&config.profile, "profile", "", "The profile of the config file to use (defaults to 'defaultProfile' of the config file)")
 	rootCmd.PersistentFlags().BoolVar(&config.useGHAuth, "use-gh-auth", false, "Use 'gh auth token' to get the GitHub authentication token")
+	rootCmd.PersistentFlags().StringVar(&config.logLevel, "log-level", "info", "The level of the logs written on stderr (debug, info, warn, error)")
+	rootCmd.PersistentFlags().StringVar(&config.logFormat, "log-format", logFormatText, fmt.Sprintf("The format of the logs written on stderr (%s, %s)", logFormatText, logFormatJson))
+	rootCmd.PersistentFlags().BoolVarP(&config.verbose, "verbose", "v", false, "Enable debug logs (same as --log-level=debug)")
+	rootCmd.PersistentFlags().StringVar(&config.forge, "forge", forgeGitHub, fmt.Sprintf("The forge hosting --repo (%s, %s, %s), the token is read from GITLAB_TOKEN or GITEA_TOKEN for the last two", forgeGitHub, forgeGitLab, forgeGitea))
+	rootCmd.PersistentFlags().StringVar(&config.forgeURL, "forge-url", "", fmt.Sprintf("The url of the forge instance (defaults to %s for %s, required for %s)", gitlab.DefaultURL, forgeGitLab, forgeGitea))
+	rootCmd.PersistentFlags().StringVar(&config.recordDir, "record", "", "Save every exchange with GitHub in this directory (with the token redacted) to replay the run later with --replay")
+	rootCmd.PersistentFlags().StringVar(&config.replayDir, "replay", "", "Serve the exchanges with GitHub from a directory created with --record instead of calling GitHub")
+	rootCmd.PersistentFlags().StringVar(&config.output, "output", string(OutputText), fmt.Sprintf("The output of all commands (%s, %s), json emits a result object with status, found and missing items and errors", OutputText, OutputJson))
 
 	rootCmd.AddCommand(versionChangelog)
 	rootCmd.AddCommand(releaseCmd)
@@ -18,14 +33,92 @@ func init() {
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	withResult(rootCmd)
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError(err)
+	})
+	if cmd, err := rootCmd.ExecuteC(); err != nil {
+		// With json output the errors are part of the result, emit one if the command failed before running (e.g. invalid flags)
+		if OutputFormat(config.output) == OutputJson && result.Status == "" {
+			result = newResult(cmd)
+			_ = result.finish(err)
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		if exitCode(err) == ExitUsage {
+			_, _ = fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", cmd.CommandPath())
+		}
+		os.Exit(exitCode(err))
 	}
 }
 
 var config Config
 
+const (
+	forgeGitHub = "github"
+	forgeGitLab = "gitlab"
+	forgeGitea  = "gitea"
+)
+
+// newForge creates the forge client used by commands, tests replace it to talk to a fake.
+var newForge = func() (github.Forge, error) {
+	if config.forge != forgeGitHub && (config.recordDir != "" || config.replayDir != "") {
+		return nil, usageErrorf("--record and --replay are only supported with --forge %s", forgeGitHub)
+	}
+	switch config.forge {
+	case forgeGitHub:
+		return newGitHubClient()
+	case forgeGitLab:
+		forgeURL := config.forgeURL
+		if forgeURL == "" {
+			forgeURL = gitlab.DefaultURL
+		}
+		return gitlab.New(forgeURL, os.Getenv("GITLAB_TOKEN"), nil), nil
+	case forgeGitea:
+		if config.forgeURL == "" {
+			return nil, usageErrorf("--forge-url is required with --forge %s", forgeGitea)
+		}
+		return gitea.New(config.forgeURL, os.Getenv("GITEA_TOKEN"), nil), nil
+	default:
+		return nil, usageErrorf("invalid --forge %q (must be %s, %s or %s)", config.forge, forgeGitHub, forgeGitLab, forgeGitea)
+	}
+}
+
+func newGitHubClient() (*github.GQLClient, error) {
+	switch {
+	case config.recordDir != "" && config.replayDir != "":
+		return nil, usageErrorf("--record and --replay are mutually exclusive")
+	case config.recordDir != "":
+		return github.NewRecordingGQLClient(config.useGHAuth, config.recordDir)
+	case config.replayDir != "":
+		return github.NewReplayGQLClient(config.replayDir)
+	default:
+		return github.NewGQLClient(config.useGHAuth)
+	}
+}
+
+// addReleaseParsingFlags adds the flags of commands extracting versions from release names.
+func addReleaseParsingFlags(flags *pflag.FlagSet) {
+	flags.StringSliceVar(&config.releaseNamePatterns, "release-name-pattern", nil, "Regexps with a capture group for the version tried on release names before the default (an optional v prefix), e.g. '^kuma-(.+)$'")
+	flags.StringVar(&config.unparsableReleases, "unparsable-releases", string(github.UnparsableSkip), fmt.Sprintf("What to do with releases whose name isn't a version (%s with a warning, %s)", github.UnparsableSkip, github.UnparsableFail))
+}
+
+// parseReleases extracts the version of releases according to --release-name-pattern and --unparsable-releases.
+func parseReleases(releases []github.Release) ([]github.ParsedRelease, error) {
+	policy := github.UnparsablePolicy(config.unparsableReleases)
+	if policy != github.UnparsableSkip && policy != github.UnparsableFail {
+		return nil, usageErrorf("invalid --unparsable-releases %q (must be %s or %s)", config.unparsableReleases, github.UnparsableSkip, github.UnparsableFail)
+	}
+	parser, err := github.NewVersionParser(config.releaseNamePatterns)
+	if err != nil {
+		return nil, usageError(err)
+	}
+	return parser.ParseReleases(releases, policy)
+}
+
+// Config holds the settings shared by commands, it's populated from flags, `RELEASE_TOOL_*` env vars
+// and the profile of the `release-tool.yaml` config file (in this order of precedence).
 type Config struct {
 	branch    string
 	repo      string
@@ -34,18 +127,77 @@ type Config struct {
 	format    string
 	release   string
 	useGHAuth bool
+	output    string
+	logLevel  string
+	logFormat string
+	verbose   bool
+
+	configFile string
+	profile    string
+	recordDir  string
+	replayDir  string
+	forge      string
+	forgeURL   string
+
+	dockerRepo          string
+	images              []string
+	registry            string
+	binaries            []string
+	urlTemplate         string
+	checksumURLTemplate string
+	chartsRepo          string
+	chartIndexURL       string
+	publicKey           string
+
+	edition           string
+	lifetimeMonths    int
+	ltsLifetimeMonths int
+	minVersion        string
+
+	eolAfterMinors      int
+	activeSupportMonths int
+	activeSupportMinors int
+
+	releaseNamePatterns []string
+	unparsableReleases  string
 }
 
 var rootCmd = &cobra.Command{
 	Use:   "release-tool",
 	Short: "Do a lot of possible release fun",
+	Long: `Do a lot of possible release fun.
+
+Errors are printed on stderr and the exit code tells the class of error:
+
+	0  success
+	1  other failure
+	2  usage error (invalid flags, config file or manifest)
+	3  authentication error (missing or refused GitHub token)
+	4  GitHub resource not found
+	5  release artifact missing or failing verification
+	6  conflict (e.g. the release is already published)
+	7  GitHub rate limit exceeded (retry later)
+	8  GitHub server or network error (retry)
+`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := initCommand(cmd); err != nil {
+			return err
+		}
 		if config.repo == "" {
-			return errors.New("must set a repo")
+			return usageErrorf("must set a repo")
 		}
-		return nil
+		return validateOutput()
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return errors.New("must pass a subcommand")
+		return usageErrorf("must pass a subcommand")
 	},
 }
+
+func validateOutput() error {
+	switch OutputFormat(config.output) {
+	case OutputText, OutputJson:
+		return nil
+	default:
+		return usageErrorf("invalid --output %q (must be %s or %s)", config.output, OutputText, OutputJson)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kumahq/ci-tools/cmd/internal/github"
+)
+
+// errSkipUpdate aborts UpsertRelease without updating the release.
+var errSkipUpdate = errors.New("skip update")
+
+var metadataFlags struct {
+	lts             bool
+	extensionMonths int
+	releasedOn      string
+	endOfLife       string
+	supportTier     string
+	securityOnly    bool
+}
+
+type metadataSetData struct {
+	Release string `json:"release"`
+	Changed bool   `json:"changed"`
+	Diff    string `json:"diff,omitempty"`
+	Body    string `json:"body"`
+}
+
+var metadataCmd = &cobra.Command{
+	Use:   "metadata",
+	Short: "Manage the metadata (LTS, extension, release date...) in the body of a release",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return usageErrorf("must pass a subcommand")
+	},
+}
+
+var metadataSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Set the metadata lines at the top of the body of a release",
+	Long: `Set the metadata lines at the top of the body of a release, e.g.:
+
+	release-tool release metadata set --release 2.7.5 --lts --extension-months 6 --released-on 2024-02-01
+
+Only the flags passed are changed, other lines are kept. Setting a flag to its zero value removes its line
+(e.g. --lts=false or --extension-months 0). Running the command twice doesn't change anything.
+It works on published releases too, the diff of the body is printed and --dry-run doesn't update the release.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		update, err := metadataUpdateFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		forge, err := newForge()
+		if err != nil {
+			return err
+		}
+
+		releaseTag := NormalizeVersionTagWithWarning(config.release)
+		releaseName := strings.TrimPrefix(releaseTag, "v")
+		release, err := forge.FindRelease(config.repo, releaseName, releaseTag)
+		if err != nil {
+			return err
+		}
+		if release == nil {
+			return fmt.Errorf("couldn't find release %s in %s: %w", releaseTag, config.repo, github.ErrNotFound)
+		}
+
+		err = forge.UpsertRelease(cmd.Context(), config.repo, releaseName, releaseTag, func(release *github.ReleaseContent) error {
+			body, err := github.SetReleaseMetadata(release.Body, update)
+			if err != nil {
+				return usageErrorf("release %s: %s", release.Name, err)
+			}
+			data := metadataSetData{Release: release.Name, Changed: body != release.Body, Diff: lineDiff(release.Body, body), Body: body}
+			result.Data = data
+			result.BodySize = len(body)
+			result.Found = append(result.Found, release.Name)
+			if !data.Changed {
+				result.printf("Metadata of release %s is already up to date\n", release.Name)
+				return errSkipUpdate
+			}
+			result.printf("--- %s\n+++ %s\n%s", release.Name, release.Name, data.Diff)
+			if dryRun {
+				return errSkipUpdate
+			}
+			release.Body = body
+			return nil
+		})
+		if errors.Is(err, errSkipUpdate) {
+			return nil
+		}
+		return err
+	},
+}
+
+// metadataUpdateFromFlags only sets the fields whose flag was passed.
+func metadataUpdateFromFlags(cmd *cobra.Command) (github.MetadataUpdate, error) {
+	var out github.MetadataUpdate
+	flags := cmd.Flags()
+	parseDate := func(name string, value string) (*time.Time, error) {
+		if value == "" {
+			return &time.Time{}, nil
+		}
+		t, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return nil, usageErrorf("invalid --%s %q (must be YYYY-MM-DD)", name, value)
+		}
+		return &t, nil
+	}
+	var err error
+	if flags.Changed("released-on") {
+		if out.ReleaseDate, err = parseDate("released-on", metadataFlags.releasedOn); err != nil {
+			return out, err
+		}
+	}
+	if flags.Changed("end-of-life") {
+		if out.EndOfLife, err = parseDate("end-of-life", metadataFlags.endOfLife); err != nil {
+			return out, err
+		}
+	}
+	if flags.Changed("lts") {
+		out.LTS = &metadataFlags.lts
+	}
+	if flags.Changed("extension-months") {
+		if metadataFlags.extensionMonths < 0 {
+			return out, usageErrorf("--extension-months can't be negative")
+		}
+		out.ExtensionMonths = &metadataFlags.extensionMonths
+	}
+	if flags.Changed("support-tier") {
+		out.SupportTier = &metadataFlags.supportTier
+	}
+	if flags.Changed("security-only") {
+		out.SecurityOnly = &metadataFlags.securityOnly
+	}
+	if out == (github.MetadataUpdate{}) {
+		return out, usageErrorf("must set at least one of --lts, --extension-months, --released-on, --end-of-life, --support-tier or --security-only")
+	}
+	return out, nil
+}
+
+// lineDiff returns the lines removed from a (prefixed with `-`) and added in b (prefixed with `+`) up to the last change, unchanged lines before it are prefixed with a space.
+func lineDiff(a, b string) string {
+	if a == b {
+		return ""
+	}
+	al, bl := strings.Split(a, "\n"), strings.Split(b, "\n")
+	// Changes are at the top of the body so dropping the common suffix keeps the LCS table small
+	for len(al) > 0 && len(bl) > 0 && al[len(al)-1] == bl[len(bl)-1] {
+		al, bl = al[:len(al)-1], bl[:len(bl)-1]
+	}
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	sb := &strings.Builder{}
+	i, j := 0, 0
+	for i < len(al) || j < len(bl) {
+		switch {
+		case i < len(al) && j < len(bl) && al[i] == bl[j]:
+			_, _ = fmt.Fprintf(sb, " %s\n", al[i])
+			i, j = i+1, j+1
+		case i < len(al) && (j == len(bl) || lcs[i+1][j] >= lcs[i][j+1]):
+			_, _ = fmt.Fprintf(sb, "-%s\n", al[i])
+			i++
+		default:
+			_, _ = fmt.Fprintf(sb, "+%s\n", bl[j])
+			j++
+		}
+	}
+	return sb.String()
+}
+
+func init() {
+	metadataSetCmd.Flags().BoolVar(&metadataFlags.lts, "lts", false, "Mark the release as LTS (--lts=false removes it)")
+	metadataSetCmd.Flags().IntVar(&metadataFlags.extensionMonths, "extension-months", 0, "The number of months the support of the release is extended by (0 removes it)")
+	metadataSetCmd.Flags().StringVar(&metadataFlags.releasedOn, "released-on", "", "The release date (YYYY-MM-DD) overriding the publication date (empty removes it)")
+	metadataSetCmd.Flags().StringVar(&metadataFlags.endOfLife, "end-of-life", "", "The end of life date (YYYY-MM-DD) overriding the computed one (empty removes it)")
+	metadataSetCmd.Flags().StringVar(&metadataFlags.supportTier, "support-tier", "", "The support tier of the release (empty removes it)")
+	metadataSetCmd.Flags().BoolVar(&metadataFlags.securityOnly, "security-only", false, "Mark the release as only receiving security fixes (--security-only=false removes it)")
+	metadataSetCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the diff without updating the release")
+
+	metadataCmd.AddCommand(metadataSetCmd)
+	releaseCmd.AddCommand(metadataCmd)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kumahq/ci-tools/cmd/internal/githubfake"
+)
+
+func TestLineDiff(t *testing.T) {
+	for _, c := range []struct {
+		a, b     string
+		expected string
+	}{
+		{a: "same\nbody", b: "same\nbody"},
+		{a: "intro\n\n## Changelog", b: "> LTS\n\nintro\n\n## Changelog", expected: "+> LTS\n+\n"},
+		{a: "> ExtensionMonths: 3\n\nintro", b: "> ExtensionMonths: 6\n\nintro", expected: "-> ExtensionMonths: 3\n+> ExtensionMonths: 6\n"},
+	} {
+		if res := lineDiff(c.a, c.b); res != c.expected {
+			t.Errorf("diff of %q and %q got:\n%q\nexpected:\n%q", c.a, c.b, res, c.expected)
+		}
+	}
+}
+
+func TestReleaseMetadataSet(t *testing.T) {
+	args := []string{"release", "metadata", "set", "--release", "2.11.0", "--extension-months", "6", "--released-on", "2025-06-19"}
+	expected := "> LTS\n> Released on 2025/06/19\n> ExtensionMonths: 6\n\nWe are excited to announce the latest release !\n\n## Changelog\n\n* feat: mesh services everywhere [#100](https://github.com/kumahq/kuma/pull/100) @alice\n"
+
+	t.Run("updates a published release idempotently", func(t *testing.T) {
+		fake := githubfake.Load(t, filepath.Join("testdata", "github.yaml"))
+		out, err := runCommand(t, fake, args...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertGolden(t, "release-metadata-set.golden", out)
+		if body := fake.Release("kumahq/kuma", "2.11.0").Body; body != expected {
+			t.Errorf("got body:\n%s", body)
+		}
+		out, err = runCommand(t, fake, args...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out != "Metadata of release 2.11.0 is already up to date\n" {
+			t.Errorf("expected no change got:\n%s", out)
+		}
+	})
+	t.Run("dry-run doesn't update the release", func(t *testing.T) {
+		fake := githubfake.Load(t, filepath.Join("testdata", "github.yaml"))
+		before := fake.Release("kumahq/kuma", "2.11.0").Body
+		out, err := runCommand(t, fake, append(args, "--dry-run")...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertGolden(t, "release-metadata-set.golden", out)
+		if after := fake.Release("kumahq/kuma", "2.11.0").Body; after != before {
+			t.Errorf("release was modified:\n%s", after)
+		}
+	})
+	t.Run("fails without metadata flag or release", func(t *testing.T) {
+		fake := githubfake.Load(t, filepath.Join("testdata", "github.yaml"))
+		if _, err := runCommand(t, fake, "release", "metadata", "set", "--release", "2.11.0"); exitCode(err) != ExitUsage {
+			t.Errorf("expected a usage error got %v", err)
+		}
+		if _, err := runCommand(t, fake, "release", "metadata", "set", "--release", "2.9.0", "--lts"); exitCode(err) != ExitNotFound {
+			t.Errorf("expected not found got %v", err)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/spf13/cobra"
+)
+
+type OutputFormat string
+
+const (
+	OutputText OutputFormat = "text"
+	OutputJson OutputFormat = "json"
+
+	statusSuccess = "success"
+	statusFailure = "failure"
+)
+
+// commandResult is what every command emits with `--output json`, in text mode it only forwards the human output.
+type commandResult struct {
+	Command  string   `json:"command"`
+	Status   string   `json:"status"`
+	Found    []string `json:"found"`
+	Missing  []string `json:"missing"`
+	BodySize int      `json:"bodySize,omitempty"`
+	Errors   []string `json:"errors"`
+	ExitCode int      `json:"exitCode"`
+	Data     any      `json:"data,omitempty"`
+
+	w    io.Writer
+	json bool
+}
+
+// result of the command currently running, it's reset before each command runs.
+var result = &commandResult{}
+
+func (r *commandResult) isJson() bool {
+	return r.json
+}
+
+// printf writes human output, it's dropped with `--output json`.
+func (r *commandResult) printf(format string, a ...any) {
+	if !r.json {
+		_, _ = fmt.Fprintf(r.w, format, a...)
+	}
+}
+
+// found records an item that was found and prints the human message.
+func (r *commandResult) found(item string, format string, a ...any) {
+	r.Found = append(r.Found, item)
+	r.printf(format, a...)
+}
+
+// missing records an item that is missing and returns err classified as a missing artifact to be aggregated by the caller.
+func (r *commandResult) missing(item string, err error) error {
+	r.Missing = append(r.Missing, item)
+	return artifactMissingError(err)
+}
+
+// finish emits the result if needed and returns err unchanged.
+func (r *commandResult) finish(err error) error {
+	r.Status = statusSuccess
+	r.ExitCode = exitCode(err)
+	if err != nil {
+		r.Status = statusFailure
+		if merr, ok := err.(*multierror.Error); ok {
+			for _, e := range merr.Errors {
+				r.Errors = append(r.Errors, e.Error())
+			}
+		} else {
+			r.Errors = append(r.Errors, err.Error())
+		}
+	}
+	if !r.json {
+		return err
+	}
+	e := json.NewEncoder(r.w)
+	e.SetIndent("", "  ")
+	if encErr := e.Encode(r); encErr != nil && err == nil {
+		return encErr
+	}
+	return err
+}
+
+// withResult wraps RunE of cmd and all its children so each of them emits a commandResult.
+func withResult(cmd *cobra.Command) {
+	for _, c := range cmd.Commands() {
+		withResult(c)
+	}
+	if cmd.RunE == nil {
+		return
+	}
+	runE := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		result = newResult(cmd)
+		return result.finish(runE(cmd, args))
+	}
+}
+
+func newResult(cmd *cobra.Command) *commandResult {
+	return &commandResult{
+		Command: strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "),
+		Found:   []string{},
+		Missing: []string{},
+		Errors:  []string{},
+		w:       cmd.OutOrStdout(),
+		json:    OutputFormat(config.output) == OutputJson,
+	}
+}
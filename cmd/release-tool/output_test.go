@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+func TestCommandResult(t *testing.T) {
+	t.Run("text only forwards human output", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		r := &commandResult{w: buf}
+		r.found("kuma-cp", "Got image: %s\n", "kuma-cp")
+		if err := r.finish(nil); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != "Got image: kuma-cp\n" {
+			t.Errorf("unexpected output %q", buf.String())
+		}
+	})
+	t.Run("json emits a result object", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		r := &commandResult{Command: "release docker", Found: []string{}, Missing: []string{}, Errors: []string{}, w: buf, json: true}
+		r.found("kumahq/kuma-cp:2.11.8", "Got image: %s\n", "kumahq/kuma-cp:2.11.8")
+		var merr *multierror.Error
+		merr = multierror.Append(merr, r.missing("kumahq/kuma-dp:2.11.8", errors.New("status: 404")))
+		err := r.finish(merr)
+		if err != merr {
+			t.Errorf("expected the error to be returned unchanged got %v", err)
+		}
+		expected := `{
+  "command": "release docker",
+  "status": "failure",
+  "found": [
+    "kumahq/kuma-cp:2.11.8"
+  ],
+  "missing": [
+    "kumahq/kuma-dp:2.11.8"
+  ],
+  "errors": [
+    "status: 404"
+  ],
+  "exitCode": 5
+}
+`
+		if buf.String() != expected {
+			t.Errorf("got:\n%s\nexpected:\n%s", buf.String(), expected)
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/spf13/cobra"
+
+	"github.com/kumahq/ci-tools/cmd/internal/github"
+	"github.com/kumahq/ci-tools/cmd/internal/registry"
+	"github.com/kumahq/ci-tools/cmd/internal/sigstore"
+)
+
+var (
+	provenanceAsset string
+)
+
+var verifyProvenanceCmd = &cobra.Command{
+	Use:   "verify-provenance",
+	Short: "Verify the SLSA provenance of the binaries and the cosign signatures of the images",
+	Long: `Verify the SLSA provenance of the binaries and the cosign signatures of the images.
+
+The '.intoto.jsonl' asset of the GitHub release is downloaded, its DSSE signature is checked against
+--public-key and each of the --binaries (rendered with --url-template) must be a subject with a matching sha256 digest.
+For each of the --images the cosign signature tag (sha256-<digest>.sig) must exist and be signed by --public-key.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if config.publicKey == "" {
+			return usageErrorf("must set --public-key")
+		}
+		if len(config.binaries) == 0 && len(config.images) == 0 {
+			return usageErrorf("need to specify some --binaries or --images to verify")
+		}
+		if len(config.images) > 0 && config.dockerRepo == "" {
+			return usageErrorf("need to specify a docker repository")
+		}
+		b, err := os.ReadFile(config.publicKey)
+		if err != nil {
+			return err
+		}
+		pub, err := sigstore.LoadPublicKey(b)
+		if err != nil {
+			return fmt.Errorf("failed to load public key %s: %w", config.publicKey, err)
+		}
+
+		var merr *multierror.Error
+		if len(config.binaries) > 0 {
+			forge, err := newForge()
+			if err != nil {
+				return err
+			}
+			assets, ok := forge.(github.AssetReader)
+			if !ok {
+				return usageErrorf("verifying the provenance of binaries requires release assets which are not supported by this forge")
+			}
+			subjects, err := fetchProvenanceSubjects(cmd.Context(), forge, assets, pub)
+			if err != nil {
+				return err
+			}
+			artifacts, err := binaryArtifacts()
+			if err != nil {
+				return err
+			}
+			for _, a := range artifacts {
+				name := artifactName(a.URL)
+				expected, found := subjects[name]
+				if !found {
+					merr = multierror.Append(merr, result.missing(name, fmt.Errorf("%s is not a subject of the provenance", name)))
+					continue
+				}
+				actual, err := fetchDigest(a.URL)
+				if err != nil {
+					merr = multierror.Append(merr, result.missing(name, err))
+					continue
+				}
+				if actual != expected {
+					merr = multierror.Append(merr, result.missing(name, fmt.Errorf("provenance digest mismatch for %s: expected sha256:%s got sha256:%s", name, expected, actual)))
+					continue
+				}
+				result.found(name, "Provenance verified: %s (sha256:%s)\n", name, actual)
+			}
+		}
+
+		regClient := registry.New(config.registry, nil)
+		releaseVersion := strings.TrimPrefix(config.release, "v")
+		for _, i := range config.images {
+			repo := fmt.Sprintf("%s/%s", config.dockerRepo, i)
+			img := fmt.Sprintf("%s:%s", repo, releaseVersion)
+			digest, err := verifyImageSignature(cmd.Context(), regClient, repo, releaseVersion, pub)
+			if err != nil {
+				merr = multierror.Append(merr, result.missing(img, fmt.Errorf("failed with image: %s %w", img, err)))
+				continue
+			}
+			result.found(img, "Signature verified: %s@%s\n", img, digest)
+		}
+		return merr.ErrorOrNil()
+	},
+}
+
+// fetchProvenanceSubjects downloads the provenance of the release, verifies it and returns the sha256 of each subject by name.
+func fetchProvenanceSubjects(ctx context.Context, finder github.ReleaseFinder, assetReader github.AssetReader, pub crypto.PublicKey) (map[string]string, error) {
+	releaseTag := NormalizeVersionTag(config.release)
+	release, err := finder.FindRelease(config.repo, strings.TrimPrefix(releaseTag, "v"), releaseTag)
+	if err != nil {
+		return nil, err
+	}
+	if release == nil {
+		return nil, fmt.Errorf("couldn't find release %s in %s: %w", config.release, config.repo, github.ErrNotFound)
+	}
+	assets, err := assetReader.ReleaseAssets(ctx, config.repo, release.Id)
+	if err != nil {
+		return nil, err
+	}
+	var assetId int64
+	for _, a := range assets {
+		if (provenanceAsset != "" && a.Name == provenanceAsset) || (provenanceAsset == "" && strings.HasSuffix(a.Name, ".intoto.jsonl")) {
+			assetId = a.Id
+			break
+		}
+	}
+	if assetId == 0 {
+		return nil, fmt.Errorf("couldn't find a provenance asset in release %s", release.Name)
+	}
+	rc, err := assetReader.DownloadReleaseAsset(ctx, config.repo, assetId)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+	envelopes, err := sigstore.ParseEnvelopes(rc)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	for _, e := range envelopes {
+		if err := e.Verify(pub); err != nil {
+			return nil, fmt.Errorf("provenance of release %s: %w", release.Name, err)
+		}
+		statement, err := e.Statement()
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range statement.Subject {
+			out[s.Name] = s.Digest["sha256"]
+		}
+	}
+	return out, nil
+}
+
+// verifyImageSignature checks that the cosign signature tag of the image exists and that one of its signatures is valid.
+func verifyImageSignature(ctx context.Context, regClient *registry.Client, repo, tag string, pub crypto.PublicKey) (string, error) {
+	digest, err := regClient.Resolve(ctx, repo, tag)
+	if err != nil {
+		return "", err
+	}
+	sigManifest, err := regClient.Manifest(ctx, repo, registry.CosignSignatureTag(digest))
+	if err != nil {
+		return "", fmt.Errorf("missing cosign signature: %w", err)
+	}
+	var merr *multierror.Error
+	for _, layer := range sigManifest.Layers {
+		sig := layer.Annotations[sigstore.CosignSignatureAnnotation]
+		if sig == "" {
+			continue
+		}
+		payload, err := regClient.Blob(ctx, repo, layer.Digest)
+		if err != nil {
+			return "", err
+		}
+		if err := sigstore.VerifyCosignPayload(pub, payload, sig, digest); err != nil {
+			merr = multierror.Append(merr, err)
+			continue
+		}
+		return digest, nil
+	}
+	if merr.ErrorOrNil() == nil {
+		return "", errors.New("no cosign signature found")
+	}
+	return "", merr
+}
+
+func init() {
+	verifyProvenanceCmd.Flags().StringVar(&config.publicKey, "public-key", "", "Path to the PEM public key used to sign the provenance and images (e.g. cosign.pub)")
+	verifyProvenanceCmd.Flags().StringVar(&provenanceAsset, "provenance-asset", "", "Name of the provenance asset in the release, defaults to the first '*.intoto.jsonl' asset")
+	verifyProvenanceCmd.Flags().StringSliceVar(&config.binaries, "binaries", nil, "A comma separated list of targets (.e.g: centos-amd64,darwin-arm64)")
+	verifyProvenanceCmd.Flags().StringVar(&config.urlTemplate, "url-template", defaultURLTemplate, "A template to use for the binary")
+	verifyProvenanceCmd.Flags().StringVar(&config.dockerRepo, "docker-repo", "", "The name of the docker repo")
+	verifyProvenanceCmd.Flags().StringSliceVar(&config.images, "images", nil, "A comma separated list of images (.e.g: kumactl,kuma-cp)")
+	verifyProvenanceCmd.Flags().StringVar(&config.registry, "registry", registry.DockerHub, "The OCI registry hosting the images")
+}
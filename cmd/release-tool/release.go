@@ -4,21 +4,31 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"path"
 	"strings"
+	"sync"
 	"text/template"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
-	github2 "github.com/google/go-github/v90/github"
 	"github.com/hashicorp/go-multierror"
 	"github.com/spf13/cobra"
 
+	"github.com/kumahq/ci-tools/cmd/internal/changeloggenerator"
+	"github.com/kumahq/ci-tools/cmd/internal/checksum"
 	"github.com/kumahq/ci-tools/cmd/internal/github"
+	"github.com/kumahq/ci-tools/cmd/internal/helmrepo"
+	"github.com/kumahq/ci-tools/cmd/internal/registry"
 )
 
 const (
 	// GitHubMaxBodySize is the maximum allowed size for GitHub release body
 	GitHubMaxBodySize = 125000
+
+	defaultURLTemplate = "https://packages.konghq.com/public/{{.Repo}}-binaries-release/raw/names/{{.Repo}}-{{.Binary}}/versions/{{.Release}}/{{.Repo}}-{{.Release}}-{{.Binary}}.tar.gz"
 )
 
 var (
@@ -58,14 +68,14 @@ TODO summary of some simple stuff.
 `
 		}
 
-		gqlClient, err := github.NewGQLClient(config.useGHAuth)
+		forge, err := newForge()
 		if err != nil {
 			return err
 		}
 
 		prevTag := NormalizeVersionTag(prevVersion.String())
 
-		fromCommit, err := gqlClient.CommitByRef(config.repo, prevTag)
+		fromCommit, err := tagCommit(forge, config.repo, prevTag)
 		if err != nil {
 			return err
 		}
@@ -74,36 +84,33 @@ TODO summary of some simple stuff.
 		// into master. Detect this by checking if the tag commit is reachable from the current
 		// branch (merge-base of tag and branch equals the tag itself). If not, fall back to the
 		// merge-base of the two release branches as the cutoff.
-		if version.Patch() == 0 && fromCommit != "" {
-			mergeBase, err := gqlClient.MergeBase(cmd.Context(), config.repo, fromCommit, branch)
+		if version.Patch() == 0 {
+			mergeBase, err := forge.MergeBase(cmd.Context(), config.repo, fromCommit, branch)
 			if err != nil {
 				return err
 			}
 			if mergeBase != fromCommit {
 				prevBranch := fmt.Sprintf("release-%d.%d", version.Major(), version.Minor()-1)
-				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "tag %s not reachable from %s, falling back to merge-base with %s\n", prevTag, branch, prevBranch)
-				fromCommit, err = gqlClient.MergeBase(cmd.Context(), config.repo, prevBranch, branch)
+				slog.Info("tag not reachable from branch, falling back to merge-base", "tag", prevTag, "branch", branch, "prevBranch", prevBranch)
+				fromCommit, err = forge.MergeBase(cmd.Context(), config.repo, prevBranch, branch)
 				if err != nil {
 					return err
 				}
 			}
 		}
 
-		_, err = fmt.Fprintf(cmd.OutOrStdout(), "getting changelog from %s on repo %s and branch %s\n", prevTag, config.repo, branch)
-		if err != nil {
-			return err
-		}
+		slog.Info("getting changelog", "from", prevTag, "repo", config.repo, "branch", branch)
 
-		changelog, err := getChangelog(gqlClient, config.repo, branch, fromCommit)
+		changelog, err := getChangelog(forge, config.repo, branch, fromCommit)
 		if err != nil {
 			return err
 		}
 
 		// Build the release body
-		buildBody := func(existingBody *string) string {
+		buildBody := func(existingBody string) string {
 			sbuilder := &strings.Builder{}
-			if existingBody != nil {
-				header = strings.SplitN(*existingBody, "## Changelog", 2)[0] + "## Changelog\n\n"
+			if existingBody != "" {
+				header = strings.SplitN(existingBody, "## Changelog", 2)[0] + "## Changelog\n\n"
 			}
 
 			sbuilder.WriteString(header)
@@ -117,25 +124,27 @@ TODO summary of some simple stuff.
 
 		// For dry-run, build and display the body without touching GitHub
 		if dryRun {
-			body := buildBody(nil)
+			body := buildBody("")
 			bodyLen := len(body)
+			result.BodySize = bodyLen
+			result.Data = releaseBodyData{Body: body, MaxBodySize: GitHubMaxBodySize, ExceedsLimit: bodyLen > GitHubMaxBodySize, Changelog: changelog}
 
-			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\n--- Release Body Preview (%d characters) ---\n", bodyLen)
-			_, _ = fmt.Fprint(cmd.OutOrStdout(), body)
-			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "--- End Preview ---\n\n")
+			result.printf("\n--- Release Body Preview (%d characters) ---\n", bodyLen)
+			result.printf("%s", body)
+			result.printf("--- End Preview ---\n\n")
 
 			if bodyLen > GitHubMaxBodySize {
-				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "⚠️  WARNING: Body exceeds GitHub limit of %d characters by %d characters\n", GitHubMaxBodySize, bodyLen-GitHubMaxBodySize)
+				result.printf("⚠️  WARNING: Body exceeds GitHub limit of %d characters by %d characters\n", GitHubMaxBodySize, bodyLen-GitHubMaxBodySize)
 			} else {
-				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "✅ Body size OK: %d/%d characters (%.1f%% of limit)\n", bodyLen, GitHubMaxBodySize, float64(bodyLen)/float64(GitHubMaxBodySize)*100)
+				result.printf("✅ Body size OK: %d/%d characters (%.1f%% of limit)\n", bodyLen, GitHubMaxBodySize, float64(bodyLen)/float64(GitHubMaxBodySize)*100)
 			}
 
 			return nil
 		}
 
 		if len(changelog) == 0 {
-			_, err := fmt.Fprintf(cmd.OutOrStdout(), "no changelog\n")
-			return err
+			result.printf("no changelog\n")
+			return nil
 		}
 
 		// Normalize release tag to match kumahq/kuma Git tag format
@@ -144,12 +153,14 @@ TODO summary of some simple stuff.
 		// Release name should not have v prefix (just the version number)
 		releaseName := strings.TrimPrefix(releaseTag, "v")
 
-		return gqlClient.UpsertRelease(cmd.Context(), config.repo, releaseName, releaseTag, func(release *github2.RepositoryRelease) error {
-			if !release.GetDraft() {
-				return fmt.Errorf("release :%s has already published release notes, updating release-notes of released versions is not supported", release)
+		return forge.UpsertRelease(cmd.Context(), config.repo, releaseName, releaseTag, func(release *github.ReleaseContent) error {
+			if !release.Draft {
+				return conflictErrorf("release :%s has already published release notes, updating release-notes of released versions is not supported", release.Name)
 			}
 
 			body := buildBody(release.Body)
+			result.BodySize = len(body)
+			result.Data = releaseBodyData{Body: body, MaxBodySize: GitHubMaxBodySize, Changelog: changelog}
 
 			// Check body size and fail with helpful message if too large
 			if len(body) > GitHubMaxBodySize {
@@ -157,8 +168,8 @@ TODO summary of some simple stuff.
 			}
 
 			// Normalize release name to not have v prefix (SLSA provenance may create releases with v prefix)
-			release.Name = github2.Ptr(releaseName)
-			release.Body = github2.Ptr(body)
+			release.Name = releaseName
+			release.Body = body
 
 			return nil
 		})
@@ -168,112 +179,384 @@ TODO summary of some simple stuff.
 var helmChartCmd = &cobra.Command{
 	Use:   "helm-chart",
 	Short: "add a reference to the helm chart in the release notes",
+	Long: `Check the helm chart of the release is published.
+
+This checks the GitHub release '<repo>-<version>' exists in --charts-repo, then fetches the helm repository index
+(--index-url, defaults to the GitHub pages of --charts-repo) and checks the chart version and appVersion match --release
+and that the chart tarball is available with the digest from the index. The images referenced in the chart's default
+values are reported.
+`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if chartRepo == "" {
-			return errors.New("must set --charts-repo")
+		if config.chartsRepo == "" {
+			return usageErrorf("must set --charts-repo")
 		}
-
-		gqlClient, err := github.NewGQLClient(config.useGHAuth)
-		if err != nil {
-			return err
+		if updateRelease && len(config.images) > 0 && config.dockerRepo == "" {
+			return usageErrorf("need to specify a docker repository")
 		}
 
-		releases, err := gqlClient.ReleaseGraphQL(chartRepo)
+		forge, err := newForge()
 		if err != nil {
 			return err
 		}
+
 		// Strip v-prefix from release version to match helm chart naming convention
 		// Git tags use v-prefix (v2.11.8) but helm charts don't (kuma-2.11.8)
 		releaseVersion := strings.TrimPrefix(config.release, "v")
-		expectedName := fmt.Sprintf("%s-%s", strings.Split(config.repo, "/")[1], releaseVersion)
-		var release *github.GQLRelease
-		for _, r := range releases {
-			if r.Name == expectedName {
-				release = &r
-				break
-			}
+		_, chartName := github.SplitRepo(config.repo)
+		expectedName := fmt.Sprintf("%s-%s", chartName, releaseVersion)
+		release, err := forge.FindRelease(config.chartsRepo, expectedName, expectedName)
+		if err != nil {
+			return err
 		}
 		if release == nil {
-			return errors.New("couldn't find matching helm charts")
+			return result.missing(expectedName, errors.New("couldn't find matching helm charts"))
+		}
+		result.found(expectedName, "Found helm chart release: %s\n", expectedName)
+
+		indexURL := config.chartIndexURL
+		if indexURL == "" {
+			indexURL = defaultChartIndexURL(config.chartsRepo)
+		}
+		chart, err := verifyHelmChart(indexURL, chartName, releaseVersion)
+		if err != nil {
+			return result.missing(fmt.Sprintf("%s:%s", indexURL, expectedName), err)
 		}
-		_, _ = cmd.OutOrStdout().Write([]byte("Found helm chart"))
-		// TODO we could update the release with a link to artifactory
+		result.Data = chart
+		result.found(chart.URL, "Found helm chart: %s (sha256:%s)\n", chart.URL, chart.Digest)
+		for _, img := range chart.Images {
+			result.printf("Chart image: %s\n", img)
+		}
+		if !updateRelease {
+			return nil
+		}
+
+		artifacts := releaseArtifacts{ChartName: expectedName, ChartURL: chart.URL}
+		artifacts.Images, err = imageReferences(cmd.Context(), registry.New(config.registry, nil), releaseVersion)
+		if err != nil {
+			return err
+		}
+		artifacts.Binaries, err = binaryArtifacts()
+		if err != nil {
+			return err
+		}
+		releaseTag := NormalizeVersionTagWithWarning(config.release)
+		releaseName := strings.TrimPrefix(releaseTag, "v")
+		err = forge.UpsertRelease(cmd.Context(), config.repo, releaseName, releaseTag, func(release *github.ReleaseContent) error {
+			if !release.Draft {
+				return conflictErrorf("release :%s is already published, updating artifacts of released versions is not supported", release.Name)
+			}
+			body := upsertArtifactsSection(release.Body, artifacts.Section())
+			if len(body) > GitHubMaxBodySize {
+				return fmt.Errorf("release body exceeds GitHub limit: %d characters (max %d)", len(body), GitHubMaxBodySize)
+			}
+			result.BodySize = len(body)
+			release.Body = body
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		result.printf("Updated artifacts of release %s\n", releaseName)
 		return nil
 	},
 }
 
+type helmChartInfo struct {
+	URL    string              `json:"url"`
+	Digest string              `json:"digest"`
+	Images []helmrepo.ImageRef `json:"images"`
+}
+
+// releaseBodyData is the json output of the release body commands.
+type releaseBodyData struct {
+	Body         string                       `json:"body"`
+	MaxBodySize  int                          `json:"maxBodySize"`
+	ExceedsLimit bool                         `json:"exceedsLimit"`
+	Changelog    changeloggenerator.Changelog `json:"changelog"`
+}
+
+// defaultChartIndexURL is where GitHub pages serves the helm repository of chartsRepo.
+func defaultChartIndexURL(chartsRepo string) string {
+	org, name := github.SplitRepo(chartsRepo)
+	return fmt.Sprintf("https://%s.github.io/%s/index.yaml", org, name)
+}
+
+// verifyHelmChart checks the chart is in the helm repository index with the expected versions and that its tarball matches the index digest.
+func verifyHelmChart(indexURL string, chartName string, releaseVersion string) (helmChartInfo, error) {
+	var out helmChartInfo
+	r, err := httpGet(indexURL)
+	if err != nil {
+		return out, err
+	}
+	index, err := helmrepo.ParseIndex(r.Body)
+	_ = r.Body.Close()
+	if err != nil {
+		return out, fmt.Errorf("%s: %w", indexURL, err)
+	}
+	entry, err := index.Get(chartName, releaseVersion)
+	if err != nil {
+		return out, err
+	}
+	if appVersion := strings.TrimPrefix(entry.AppVersion, "v"); appVersion != releaseVersion {
+		return out, fmt.Errorf("chart %s-%s has appVersion %s instead of %s", chartName, entry.Version, entry.AppVersion, releaseVersion)
+	}
+	if entry.Digest == "" {
+		return out, fmt.Errorf("index has no digest for chart %s-%s", chartName, entry.Version)
+	}
+	out.URL, err = entry.ChartURL(indexURL)
+	if err != nil {
+		return out, err
+	}
+	r, err = httpGet(out.URL)
+	if err != nil {
+		return out, err
+	}
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	digest, images, err := helmrepo.InspectChart(r.Body, chartName)
+	if err != nil {
+		return out, fmt.Errorf("%s: %w", out.URL, err)
+	}
+	if entry.Digest != digest {
+		return out, fmt.Errorf("chart digest mismatch for %s: index has sha256:%s got sha256:%s", out.URL, entry.Digest, digest)
+	}
+	out.Digest, out.Images = digest, images
+	return out, nil
+}
+
+type binaryTemplateData struct {
+	Org     string
+	Repo    string
+	Binary  string
+	Release string
+	URL     string
+}
+
 var binariesCmd = &cobra.Command{
 	Use:   "binaries",
 	Short: "Check all binaries are present in the right place",
+	Long: `Check all binaries are present in the right place.
+
+If --checksum-url-template is set each binary is downloaded and its SHA-256 is verified against
+the published checksum file (either a goreleaser 'checksums.txt' or a per-binary '.sha256' file).
+Mismatches and missing checksum entries are reported as errors.
+`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if len(binaries) == 0 {
-			return errors.New("need to specific at least one binary")
+		if len(config.binaries) == 0 {
+			return usageErrorf("need to specific at least one binary")
 		}
 		var merr *multierror.Error
-		org, name := github.SplitRepo(config.repo)
-		tmpl, err := template.New("").Parse(urlTemplate)
+		artifacts, err := binaryArtifacts()
 		if err != nil {
 			return err
 		}
-		// Strip v-prefix from release version to match binary naming convention
-		releaseVersion := strings.TrimPrefix(config.release, "v")
-		for _, binary := range binaries {
-			buf := bytes.NewBuffer(nil)
-			err := tmpl.Execute(buf, struct {
-				Org     string
-				Repo    string
-				Binary  string
-				Release string
-			}{
-				Org: org, Repo: name, Binary: binary, Release: releaseVersion,
-			})
-			if err != nil {
-				return err
-			}
-			u := buf.String()
-			if err != nil {
-				merr = multierror.Append(merr, fmt.Errorf("couldn't join url path: %w", err))
-				continue
-			}
-			r, err := http.Get(u)
-			if err != nil {
-				merr = multierror.Append(merr, fmt.Errorf("couldn't get %s: %w", u, err))
-			} else if r.StatusCode != 200 {
-				merr = multierror.Append(merr, fmt.Errorf("couldn't get %s: %d", u, r.StatusCode))
-			} else {
-				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Found: %s\n", u)
+		checksums, err := newChecksumCache(config.checksumURLTemplate)
+		if err != nil {
+			return err
+		}
+		for _, data := range artifacts {
+			digest, err := verifyBinary(data, checksums)
+			switch {
+			case err != nil:
+				merr = multierror.Append(merr, result.missing(data.URL, err))
+			case digest != "":
+				result.found(data.URL, "Found: %s (sha256:%s)\n", data.URL, digest)
+			default:
+				result.found(data.URL, "Found: %s\n", data.URL)
 			}
-			_ = r.Body.Close()
 		}
 		return merr.ErrorOrNil()
 	},
 }
 
+// checksumCache fetches checksum files once as they are usually shared by all binaries.
+type checksumCache struct {
+	tmpl *template.Template
+
+	mu    sync.Mutex
+	files map[string]*checksumFile
+}
+
+// checksumFile is fetched once, concurrent lookups wait for the first fetch without blocking other files.
+type checksumFile struct {
+	once sync.Once
+	sums checksum.Sums
+	err  error
+}
+
+// newChecksumCache returns nil if tmpl is empty which disables checksum verification.
+func newChecksumCache(tmpl string) (*checksumCache, error) {
+	if tmpl == "" {
+		return nil, nil
+	}
+	t, err := template.New("").Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	return &checksumCache{tmpl: t, files: map[string]*checksumFile{}}, nil
+}
+
+// expected returns the published digest of the binary.
+func (c *checksumCache) expected(data binaryTemplateData) (string, error) {
+	checksumURL, err := executeTemplate(c.tmpl, data)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	file, found := c.files[checksumURL]
+	if !found {
+		file = &checksumFile{}
+		c.files[checksumURL] = file
+	}
+	c.mu.Unlock()
+	file.once.Do(func() {
+		file.sums, file.err = fetchChecksums(checksumURL)
+	})
+	if file.err != nil {
+		return "", file.err
+	}
+	digest, err := file.sums.Lookup(artifactName(data.URL))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", checksumURL, err)
+	}
+	return digest, nil
+}
+
+// verifyBinary checks the binary exists, if checksums is set it also verifies its digest and returns it.
+func verifyBinary(data binaryTemplateData, checksums *checksumCache) (string, error) {
+	if checksums == nil {
+		return "", checkURL(data.URL)
+	}
+	expected, err := checksums.expected(data)
+	if err != nil {
+		return "", err
+	}
+	actual, err := fetchDigest(data.URL)
+	if err != nil {
+		return "", err
+	}
+	if actual != expected {
+		return "", fmt.Errorf("checksum mismatch for %s: expected sha256:%s got sha256:%s", data.URL, expected, actual)
+	}
+	return actual, nil
+}
+
+// binaryArtifacts renders --url-template for each of the --binaries.
+func binaryArtifacts() ([]binaryTemplateData, error) {
+	return renderBinaryArtifacts(config.repo, config.release, config.urlTemplate, config.binaries)
+}
+
+func renderBinaryArtifacts(repo string, release string, urlTmpl string, names []string) ([]binaryTemplateData, error) {
+	org, name := github.SplitRepo(repo)
+	tmpl, err := template.New("").Parse(urlTmpl)
+	if err != nil {
+		return nil, err
+	}
+	// Strip v-prefix from release version to match binary naming convention
+	releaseVersion := strings.TrimPrefix(release, "v")
+	var out []binaryTemplateData
+	for _, binary := range names {
+		data := binaryTemplateData{Org: org, Repo: name, Binary: binary, Release: releaseVersion}
+		data.URL, err = executeTemplate(tmpl, data)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, data)
+	}
+	return out, nil
+}
+
+func executeTemplate(tmpl *template.Template, data any) (string, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// artifactName returns the file name of the artifact at u ignoring any query string.
+func artifactName(u string) string {
+	if parsed, err := url.Parse(u); err == nil {
+		return path.Base(parsed.Path)
+	}
+	return path.Base(u)
+}
+
+// httpGet issues a GET and returns the response only if it's a 200.
+func httpGet(u string) (*http.Response, error) {
+	start := time.Now()
+	r, err := http.Get(u)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get %s: %w", u, err)
+	}
+	if r.StatusCode != http.StatusOK {
+		_ = r.Body.Close()
+		return nil, fmt.Errorf("couldn't get %s: %d", u, r.StatusCode)
+	}
+	slog.Debug("fetched artifact", "url", u, "status", r.StatusCode, "duration", time.Since(start))
+	return r, nil
+}
+
+func checkURL(u string) error {
+	r, err := httpGet(u)
+	if err != nil {
+		return err
+	}
+	return r.Body.Close()
+}
+
+func fetchChecksums(u string) (checksum.Sums, error) {
+	r, err := httpGet(u)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	sums, err := checksum.Parse(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse checksums %s: %w", u, err)
+	}
+	return sums, nil
+}
+
+// fetchDigest streams the artifact at u and returns its SHA-256 without storing it.
+func fetchDigest(u string) (string, error) {
+	r, err := httpGet(u)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	d, err := checksum.Digest(r.Body)
+	if err != nil {
+		return "", fmt.Errorf("couldn't download %s: %w", u, err)
+	}
+	return d, nil
+}
+
 var (
-	dockerImages     []string
-	dockerRepository string
-	dockerCmd        = &cobra.Command{
+	dockerCmd = &cobra.Command{
 		Use:   "docker",
 		Short: "Check all images",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(dockerImages) == 0 {
-				return errors.New("need to specify some docker images")
+			if len(config.images) == 0 {
+				return usageErrorf("need to specify some docker images")
 			}
-			if dockerRepository == "" {
-				return errors.New("need to specify a docker repository")
+			if config.dockerRepo == "" {
+				return usageErrorf("need to specify a docker repository")
 			}
 			// Strip v-prefix from release version to match Docker tag naming convention
 			releaseVersion := strings.TrimPrefix(config.release, "v")
 			var merr *multierror.Error
-			for _, i := range dockerImages {
-				img := fmt.Sprintf("%s/%s:%s", dockerRepository, i, releaseVersion)
-				r, err := http.Head(fmt.Sprintf("https://hub.docker.com/v2/repositories/%s/%s/tags/%s", dockerRepository, i, releaseVersion))
-				if err != nil {
-					merr = multierror.Append(merr, fmt.Errorf("failed with image: %s %w", img, err))
-				} else if r.StatusCode != 200 {
-					merr = multierror.Append(merr, fmt.Errorf("failed with image: %s status: %d", img, r.StatusCode))
+			for _, i := range config.images {
+				img := fmt.Sprintf("%s/%s:%s", config.dockerRepo, i, releaseVersion)
+				if err := checkDockerImage(config.dockerRepo, i, releaseVersion); err != nil {
+					merr = multierror.Append(merr, result.missing(img, err))
 				} else {
-					_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Got image: %s\n", img)
+					result.found(img, "Got image: %s\n", img)
 				}
 			}
 			return merr.ErrorOrNil()
@@ -281,52 +564,77 @@ var (
 	}
 )
 
+// checkDockerImage checks the tag exists on Docker Hub.
+func checkDockerImage(dockerRepo, image, tag string) error {
+	img := fmt.Sprintf("%s/%s:%s", dockerRepo, image, tag)
+	r, err := http.Head(fmt.Sprintf("https://hub.docker.com/v2/repositories/%s/%s/tags/%s", dockerRepo, image, tag))
+	if err != nil {
+		return fmt.Errorf("failed with image: %s %w", img, err)
+	}
+	_ = r.Body.Close()
+	if r.StatusCode != 200 {
+		return fmt.Errorf("failed with image: %s status: %d", img, r.StatusCode)
+	}
+	return nil
+}
+
 var releaseCmd = &cobra.Command{
 	Use:   "release",
 	Short: "Do a lot of possible release fun",
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		if config.repo == "" {
-			return errors.New("you must have a valid `--repo`")
-		}
-		if config.release == "" {
-			return errors.New("you must set `--release`")
+		if err := initCommand(cmd); err != nil {
+			return err
 		}
-
-		var err error
-		version, err = semver.NewVersion(config.release)
-		return err
+		return usageError(validateReleaseFlags())
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return errors.New("must pass a subcommand")
+		return usageErrorf("must pass a subcommand")
 	},
 }
 
+// validateReleaseFlags checks the flags shared by all release commands and parses the version.
+func validateReleaseFlags() error {
+	if config.repo == "" {
+		return usageErrorf("you must have a valid `--repo`")
+	}
+	if config.release == "" {
+		return usageErrorf("you must set `--release`")
+	}
+	if err := validateOutput(); err != nil {
+		return err
+	}
+
+	var err error
+	version, err = semver.NewVersion(config.release)
+	return err
+}
+
 var (
-	binaries    []string
-	chartRepo   string
-	urlTemplate string
+	updateRelease bool
 )
 
 func init() {
-	githubReleaseChangelogCmd.Flags().StringVar(&config.release, "release", "", "The name of the release to publish")
-	githubReleaseChangelogCmd.Flags().StringVar(&config.repo, "repo", "kumahq/kuma", "The repository to query")
+	releaseCmd.PersistentFlags().StringVar(&config.release, "release", "", "The name of the release")
 	githubReleaseChangelogCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview the release body without updating GitHub")
-	helmChartCmd.Flags().StringVar(&chartRepo, "charts-repo", "", "The repository to query")
-	helmChartCmd.Flags().StringVar(&config.repo, "repo", "kumahq/kuma", "The repository to query")
-	helmChartCmd.Flags().StringVar(&config.release, "release", "", "The name of the release to publish")
-
-	binariesCmd.Flags().StringVar(&config.repo, "repo", "kumahq/kuma", "The repository to query")
-	binariesCmd.Flags().StringVar(&config.release, "release", "", "The name of the release to publish")
-	binariesCmd.Flags().StringSliceVar(&binaries, "binaries", binaries, "A comma separated list of targets (.e.g: centos-amd64,darwin-arm64)")
-	binariesCmd.Flags().StringVar(&urlTemplate, "url-template", "https://packages.konghq.com/public/{{.Repo}}-binaries-release/raw/names/{{.Repo}}-{{.Binary}}/versions/{{.Release}}/{{.Repo}}-{{.Release}}-{{.Binary}}.tar.gz", "A template to use for the binary")
-
-	dockerCmd.Flags().StringVar(&config.repo, "repo", "kumahq/kuma", "The repository to query")
-	dockerCmd.Flags().StringVar(&config.release, "release", "", "The name of the release to publish")
-	dockerCmd.Flags().StringVar(&dockerRepository, "docker-repo", "", "The name of the docker repo")
-	dockerCmd.Flags().StringSliceVar(&dockerImages, "images", dockerImages, "A comma separated list of images (.e.g: kumactl,kuma-cp)")
+	helmChartCmd.Flags().StringVar(&config.chartsRepo, "charts-repo", "", "The repository to query")
+	helmChartCmd.Flags().BoolVar(&updateRelease, "update-release", false, "Add or refresh the '## Artifacts' section of the draft release with the chart, images and binaries")
+	helmChartCmd.Flags().StringVar(&config.dockerRepo, "docker-repo", "", "The name of the docker repo (used with --update-release)")
+	helmChartCmd.Flags().StringSliceVar(&config.images, "images", nil, "A comma separated list of images (.e.g: kumactl,kuma-cp) (used with --update-release)")
+	helmChartCmd.Flags().StringVar(&config.registry, "registry", registry.DockerHub, "The OCI registry hosting the images")
+	helmChartCmd.Flags().StringSliceVar(&config.binaries, "binaries", nil, "A comma separated list of targets (.e.g: centos-amd64,darwin-arm64) (used with --update-release)")
+	helmChartCmd.Flags().StringVar(&config.urlTemplate, "url-template", defaultURLTemplate, "A template to use for the binary")
+	helmChartCmd.Flags().StringVar(&config.chartIndexURL, "index-url", "", "The url of the helm repository index.yaml (defaults to https://<org>.github.io/<name>/index.yaml of --charts-repo)")
+
+	binariesCmd.Flags().StringSliceVar(&config.binaries, "binaries", nil, "A comma separated list of targets (.e.g: centos-amd64,darwin-arm64)")
+	binariesCmd.Flags().StringVar(&config.urlTemplate, "url-template", defaultURLTemplate, "A template to use for the binary")
+	binariesCmd.Flags().StringVar(&config.checksumURLTemplate, "checksum-url-template", "", "A template for the checksum file to verify binaries against (e.g. '{{.URL}}.sha256' or a goreleaser 'checksums.txt' url), disabled if empty")
+
+	dockerCmd.Flags().StringVar(&config.dockerRepo, "docker-repo", "", "The name of the docker repo")
+	dockerCmd.Flags().StringSliceVar(&config.images, "images", nil, "A comma separated list of images (.e.g: kumactl,kuma-cp)")
 
 	releaseCmd.AddCommand(githubReleaseChangelogCmd)
 	releaseCmd.AddCommand(helmChartCmd)
 	releaseCmd.AddCommand(binariesCmd)
 	releaseCmd.AddCommand(dockerCmd)
+	releaseCmd.AddCommand(verifyProvenanceCmd)
 }
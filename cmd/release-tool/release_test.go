@@ -1,6 +1,9 @@
 package main
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 )
@@ -222,3 +225,89 @@ func TestReleaseTagNormalization(t *testing.T) {
 		})
 	}
 }
+
+func TestUpsertArtifactsSection(t *testing.T) {
+	section := releaseArtifacts{
+		ChartName: "kuma-2.11.8",
+		ChartURL:  "https://kumahq.github.io/charts/kuma-2.11.8.tgz",
+		Images:    []string{"kumahq/kuma-cp:2.11.8@sha256:abcd"},
+		Binaries:  []binaryTemplateData{{Binary: "linux-amd64", URL: "https://example.com/kuma-2.11.8-linux-amd64.tar.gz"}},
+	}.Section()
+	expectedSection := `<!-- release-tool:artifacts:start -->
+## Artifacts
+
+### Helm chart
+
+* [kuma-2.11.8](https://kumahq.github.io/charts/kuma-2.11.8.tgz)
+
+### Docker images
+
+* ` + "`kumahq/kuma-cp:2.11.8@sha256:abcd`" + `
+
+### Binaries
+
+* [linux-amd64](https://example.com/kuma-2.11.8-linux-amd64.tar.gz)
+
+<!-- release-tool:artifacts:end -->
+`
+	if section != expectedSection {
+		t.Fatalf("unexpected section:\n%s", section)
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "empty body",
+			body:     "",
+			expected: expectedSection,
+		},
+		{
+			name:     "inserted before changelog",
+			body:     "Intro\n\n## Changelog\n\n* foo\n",
+			expected: "Intro\n\n" + expectedSection + "\n## Changelog\n\n* foo\n",
+		},
+		{
+			name:     "appended without changelog",
+			body:     "Intro",
+			expected: "Intro\n\n" + expectedSection,
+		},
+		{
+			name:     "replaces existing section",
+			body:     "Intro\n\n" + artifactsStartMarker + "\nold\n" + artifactsEndMarker + "\n\n## Changelog\n",
+			expected: "Intro\n\n" + expectedSection + "\n## Changelog\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := upsertArtifactsSection(tt.body, section)
+			if got != tt.expected {
+				t.Errorf("got:\n%q\nexpected:\n%q", got, tt.expected)
+			}
+			if again := upsertArtifactsSection(got, section); again != got {
+				t.Errorf("not idempotent, got:\n%q", again)
+			}
+		})
+	}
+}
+
+func TestVerifyHelmChartWithoutDigest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/index.yaml" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, "apiVersion: v1\nentries:\n  kuma:\n  - name: kuma\n    version: 2.11.8\n    appVersion: 2.11.8\n    urls:\n    - kuma-2.11.8.tgz\n")
+	}))
+	defer srv.Close()
+	chart, err := verifyHelmChart(srv.URL+"/index.yaml", "kuma", "2.11.8")
+	if err == nil || !strings.Contains(err.Error(), "index has no digest for chart kuma-2.11.8") {
+		t.Errorf("expected a missing digest error got %v", err)
+	}
+	if chart.Digest != "" {
+		t.Errorf("digest %s wasn't compared", chart.Digest)
+	}
+}
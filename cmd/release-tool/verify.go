@@ -0,0 +1,328 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kumahq/ci-tools/cmd/internal/github"
+)
+
+const (
+	releaseStateDraft     = "draft"
+	releaseStatePublished = "published"
+)
+
+// verifyManifest describes everything that must be available for a release to be published.
+type verifyManifest struct {
+	Repo    string `yaml:"repo"`
+	Release string `yaml:"release"`
+	// ReleaseState is the expected state of the GitHub release (draft or published), not checked if empty
+	ReleaseState string `yaml:"releaseState"`
+	Binaries     struct {
+		Names               []string `yaml:"names"`
+		URLTemplate         string   `yaml:"urlTemplate"`
+		ChecksumURLTemplate string   `yaml:"checksumUrlTemplate"`
+	} `yaml:"binaries"`
+	Images struct {
+		Repository string   `yaml:"repository"`
+		Names      []string `yaml:"names"`
+	} `yaml:"images"`
+	Charts []struct {
+		// Repo is the GitHub repository where the chart release `<name>-<version>` is created
+		Repo     string `yaml:"repo"`
+		Name     string `yaml:"name"`
+		IndexURL string `yaml:"indexUrl"`
+	} `yaml:"charts"`
+}
+
+func loadVerifyManifest(path string) (verifyManifest, error) {
+	var out verifyManifest
+	f, err := os.Open(path)
+	if err != nil {
+		return out, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&out); err != nil {
+		return out, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	if out.Binaries.URLTemplate == "" {
+		out.Binaries.URLTemplate = defaultURLTemplate
+	}
+	switch out.ReleaseState {
+	case "", releaseStateDraft, releaseStatePublished:
+	default:
+		return out, fmt.Errorf("invalid releaseState %q in manifest %s (must be %s or %s)", out.ReleaseState, path, releaseStateDraft, releaseStatePublished)
+	}
+	if len(out.Images.Names) > 0 && out.Images.Repository == "" {
+		return out, fmt.Errorf("images.repository must be set in manifest %s", path)
+	}
+	return out, nil
+}
+
+type checkResult struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type verifyReport struct {
+	Repo    string        `json:"repo"`
+	Release string        `json:"release"`
+	Passed  bool          `json:"passed"`
+	Checks  []checkResult `json:"checks"`
+}
+
+func (r verifyReport) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "KIND\tNAME\tSTATUS\tDETAIL")
+	for _, c := range r.Checks {
+		status, detail := "PASS", c.Detail
+		if !c.Passed {
+			status, detail = "FAIL", c.Error
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Kind, c.Name, status, detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	result := "PASSED"
+	if !r.Passed {
+		result = "FAILED"
+	}
+	_, err := fmt.Fprintf(w, "\nRelease %s of %s: %s\n", r.Release, r.Repo, result)
+	return err
+}
+
+type check struct {
+	kind string
+	name string
+	// run returns a detail about what was found
+	run func() (string, error)
+}
+
+// runChecks runs all checks with at most concurrency in parallel, results are in the same order as checks.
+func runChecks(checks []check, concurrency int) []checkResult {
+	out := make([]checkResult, len(checks))
+	sem := make(chan struct{}, max(concurrency, 1))
+	wg := sync.WaitGroup{}
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			detail, err := c.run()
+			out[i] = checkResult{Kind: c.kind, Name: c.name, Passed: err == nil, Detail: detail}
+			if err != nil {
+				out[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+	return out
+}
+
+var (
+	manifestPath      string
+	verifyFormat      string
+	verifyConcurrency int
+	manifest          verifyManifest
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check a release is ready to publish by running all artifact checks from a manifest",
+	Long: `Check a release is ready to publish by running all artifact checks from a manifest.
+
+The manifest is a yaml file describing what is expected for the release:
+
+	repo: kumahq/kuma
+	release: 2.11.8
+	releaseState: draft
+	binaries:
+	  names: [linux-amd64, darwin-arm64]
+	  urlTemplate: https://...   # defaults to the one of 'release binaries'
+	  checksumUrlTemplate: https://...
+	images:
+	  repository: kumahq
+	  names: [kuma-cp, kuma-dp, kumactl]
+	charts:
+	- repo: kumahq/charts
+	  name: kuma
+
+All checks run concurrently and a single report is printed, the command fails if any check failed.
+--repo and --release override the values of the manifest.
+`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := initCommand(cmd); err != nil {
+			return err
+		}
+		if manifestPath == "" {
+			return usageErrorf("must set --manifest")
+		}
+		var err error
+		manifest, err = loadVerifyManifest(manifestPath)
+		if err != nil {
+			return usageError(err)
+		}
+		if !cmd.Flags().Changed("repo") && manifest.Repo != "" {
+			config.repo = manifest.Repo
+		}
+		if config.release == "" && manifest.Release != "" {
+			config.release = manifest.Release
+		}
+		return usageError(validateReleaseFlags())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if verifyFormat != "table" && verifyFormat != string(FormatJson) {
+			return usageErrorf("invalid --format %q (must be table or json)", verifyFormat)
+		}
+		releaseVersion := strings.TrimPrefix(config.release, "v")
+		var checks []check
+
+		var forge github.Forge
+		if manifest.ReleaseState != "" || len(manifest.Charts) > 0 {
+			var err error
+			forge, err = newForge()
+			if err != nil {
+				return err
+			}
+		}
+
+		if manifest.ReleaseState != "" {
+			releaseTag := NormalizeVersionTag(config.release)
+			checks = append(checks, check{kind: "release", name: releaseTag, run: func() (string, error) {
+				release, err := forge.FindRelease(config.repo, strings.TrimPrefix(releaseTag, "v"), releaseTag)
+				if err != nil {
+					return "", err
+				}
+				if release == nil {
+					return "", fmt.Errorf("couldn't find release %s in %s: %w", releaseTag, config.repo, github.ErrNotFound)
+				}
+				state := releaseStatePublished
+				if release.IsDraft {
+					state = releaseStateDraft
+				}
+				if state != manifest.ReleaseState {
+					return "", fmt.Errorf("release is %s instead of %s", state, manifest.ReleaseState)
+				}
+				return state, nil
+			}})
+		}
+
+		artifacts, err := renderBinaryArtifacts(config.repo, config.release, manifest.Binaries.URLTemplate, manifest.Binaries.Names)
+		if err != nil {
+			return err
+		}
+		checksums, err := newChecksumCache(manifest.Binaries.ChecksumURLTemplate)
+		if err != nil {
+			return err
+		}
+		for _, a := range artifacts {
+			checks = append(checks, check{kind: "binary", name: a.Binary, run: func() (string, error) {
+				digest, err := verifyBinary(a, checksums)
+				if err != nil {
+					return "", err
+				}
+				if digest != "" {
+					return fmt.Sprintf("%s (sha256:%s)", a.URL, digest), nil
+				}
+				return a.URL, nil
+			}})
+		}
+
+		for _, i := range manifest.Images.Names {
+			checks = append(checks, check{kind: "image", name: i, run: func() (string, error) {
+				return fmt.Sprintf("%s/%s:%s", manifest.Images.Repository, i, releaseVersion), checkDockerImage(manifest.Images.Repository, i, releaseVersion)
+			}})
+		}
+
+		for _, c := range manifest.Charts {
+			name := c.Name
+			if name == "" {
+				_, name = github.SplitRepo(config.repo)
+			}
+			indexURL := c.IndexURL
+			if indexURL == "" {
+				if c.Repo == "" {
+					return usageErrorf("chart %s must have either a repo or an indexUrl", name)
+				}
+				indexURL = defaultChartIndexURL(c.Repo)
+			}
+			checks = append(checks, check{kind: "chart", name: name, run: func() (string, error) {
+				if c.Repo != "" {
+					chartRelease := fmt.Sprintf("%s-%s", name, releaseVersion)
+					release, err := forge.FindRelease(c.Repo, chartRelease, chartRelease)
+					if err != nil {
+						return "", err
+					}
+					if release == nil {
+						return "", fmt.Errorf("couldn't find release %s in %s: %w", chartRelease, c.Repo, github.ErrNotFound)
+					}
+				}
+				chart, err := verifyHelmChart(indexURL, name, releaseVersion)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%s (sha256:%s)", chart.URL, chart.Digest), nil
+			}})
+		}
+
+		if len(checks) == 0 {
+			return usageErrorf("manifest %s doesn't define any check", manifestPath)
+		}
+
+		report := verifyReport{Repo: config.repo, Release: config.release, Passed: true, Checks: runChecks(checks, verifyConcurrency)}
+		failed := 0
+		for _, c := range report.Checks {
+			item := fmt.Sprintf("%s:%s", c.Kind, c.Name)
+			if c.Passed {
+				result.Found = append(result.Found, item)
+			} else {
+				report.Passed = false
+				failed++
+				result.Missing = append(result.Missing, item)
+			}
+		}
+		result.Data = report
+		switch {
+		case result.isJson():
+			err = nil
+		case verifyFormat == string(FormatJson):
+			e := json.NewEncoder(cmd.OutOrStdout())
+			e.SetIndent("", "  ")
+			err = e.Encode(report)
+		default:
+			err = report.writeTable(cmd.OutOrStdout())
+		}
+		if err != nil {
+			return err
+		}
+		if failed > 0 {
+			return artifactMissingError(fmt.Errorf("release verification failed: %d of %d checks failed", failed, len(report.Checks)))
+		}
+		return nil
+	},
+}
+
+func init() {
+	verifyCmd.Flags().StringVar(&manifestPath, "manifest", "", "Path to the yaml manifest describing the expected release artifacts")
+	verifyCmd.Flags().StringVar(&verifyFormat, "format", "table", fmt.Sprintf("The output format (table, %s)", FormatJson))
+	verifyCmd.Flags().IntVar(&verifyConcurrency, "concurrency", 8, "The maximum number of checks to run in parallel")
+
+	releaseCmd.AddCommand(verifyCmd)
+}
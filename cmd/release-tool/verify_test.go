@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunChecks(t *testing.T) {
+	checks := []check{
+		{kind: "binary", name: "linux-amd64", run: func() (string, error) { return "found", nil }},
+		{kind: "image", name: "kuma-cp", run: func() (string, error) { return "", errors.New("status: 404") }},
+		{kind: "chart", name: "kuma", run: func() (string, error) { return "chart", nil }},
+	}
+	res := runChecks(checks, 2)
+	if len(res) != 3 {
+		t.Fatalf("expected 3 results got %d", len(res))
+	}
+	expected := []checkResult{
+		{Kind: "binary", Name: "linux-amd64", Passed: true, Detail: "found"},
+		{Kind: "image", Name: "kuma-cp", Error: "status: 404"},
+		{Kind: "chart", Name: "kuma", Passed: true, Detail: "chart"},
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("result %d got %+v expected %+v", i, res[i], expected[i])
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := (verifyReport{Repo: "kumahq/kuma", Release: "2.11.8", Checks: res}).writeTable(buf); err != nil {
+		t.Fatal(err)
+	}
+	expectedTable := `KIND    NAME         STATUS  DETAIL
+binary  linux-amd64  PASS    found
+image   kuma-cp      FAIL    status: 404
+chart   kuma         PASS    chart
+
+Release 2.11.8 of kumahq/kuma: FAILED
+`
+	if buf.String() != expectedTable {
+		t.Errorf("got:\n%s\nexpected:\n%s", buf.String(), expectedTable)
+	}
+}
+
+func TestLoadVerifyManifest(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		p := filepath.Join(dir, "manifest.yaml")
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	m, err := loadVerifyManifest(write(`
+repo: Kong/kong-mesh
+release: 2.11.8
+releaseState: draft
+binaries:
+  names: [linux-amd64]
+images:
+  repository: kong
+  names: [kuma-cp]
+charts:
+- repo: Kong/charts
+  name: kong-mesh
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Repo != "Kong/kong-mesh" || m.Binaries.URLTemplate != defaultURLTemplate || len(m.Charts) != 1 || m.Charts[0].Name != "kong-mesh" {
+		t.Errorf("unexpected manifest %+v", m)
+	}
+
+	for _, invalid := range []string{
+		"releaseState: unknown\n",
+		"images:\n  names: [kuma-cp]\n",
+		"unknownField: true\n",
+	} {
+		if _, err := loadVerifyManifest(write(invalid)); err == nil || !strings.Contains(err.Error(), "manifest") {
+			t.Errorf("expected an error for %q got %v", invalid, err)
+		}
+	}
+}
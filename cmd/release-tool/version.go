@@ -1,8 +1,7 @@
 package main
 
 import (
-	"fmt"
-	"os"
+	"log/slog"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
@@ -40,14 +39,14 @@ func normalizeVersionTag(tag string, warn bool) string {
 
 	if needsVPrefix(v) {
 		if !hasPrefix && warn {
-			_, _ = fmt.Fprintf(os.Stderr, "Warning: auto-adding 'v' prefix to tag %s -> v%s (kumahq/kuma uses v-prefixed tags for this version)\n", tag, tag)
+			slog.Warn("auto-adding 'v' prefix to tag (kumahq/kuma uses v-prefixed tags for this version)", "tag", tag, "normalized", "v"+cleanTag)
 		}
 
 		return "v" + cleanTag
 	}
 
 	if hasPrefix && warn {
-		_, _ = fmt.Fprintf(os.Stderr, "Warning: auto-removing 'v' prefix from tag %s -> %s (kumahq/kuma uses non-prefixed tags for this version)\n", tag, cleanTag)
+		slog.Warn("auto-removing 'v' prefix from tag (kumahq/kuma uses non-prefixed tags for this version)", "tag", tag, "normalized", cleanTag)
 	}
 
 	return cleanTag
@@ -3,8 +3,11 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"os"
 	"regexp"
-	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
@@ -12,15 +15,27 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/kumahq/ci-tools/cmd/internal/github"
+	"github.com/kumahq/ci-tools/cmd/internal/renovate"
 	"github.com/kumahq/ci-tools/cmd/internal/versionfile"
 )
 
+const (
+	FormatYaml      OutFormat = "yaml"
+	FormatEndOfLife OutFormat = "endoflife"
+	FormatICS       OutFormat = "ics"
+	FormatGHAMatrix OutFormat = "gha-matrix"
+
+	envGitHubOutput = "GITHUB_OUTPUT"
+)
+
 var (
-	lifetimeMonths    int
-	ltsLifetimeMonths int
-	edition           string
-	minVersion        string
 	activeBranches    bool
+	versionFileFormat string
+	renovateFile      string
+	securityOnlyDays  int
+	githubOutput      string
+	asOf              string
+	editionSources    []string
 )
 
 type ActiveBranches struct {
@@ -32,69 +47,363 @@ var versionFile = &cobra.Command{
 	Short: "Recreate the versions.yaml using github releases",
 	Long: `
 	We use metadata from github to generate the versions file
+
+With --format endoflife the release cycles are printed as json in the schema of https://endoflife.date
+(cycle, releaseDate, eol, latest, latestReleaseDate, lts and support), versions not released yet are omitted.
+With --format ics an iCalendar (RFC 5545) with all-day events for the release date and end of life of each version is printed,
+the UID of events are stable so calendars subscribed to it update them in place.
+With --format gha-matrix the versions not EOL are printed on one line as a GitHub Actions matrix
+({"include": [{"branch", "version", "latest", "lts", "daysUntilEOL"}]}), with --github-output it's also written
+to $GITHUB_OUTPUT to be used in a later job, e.g.:
+
+	release-tool version-file --format gha-matrix --github-output matrix
+	# strategy: {matrix: "${{ fromJSON(needs.versions.outputs.matrix) }}"}
+
+With --renovate-file the branches not EOL are merged in the baseBranchPatterns of an existing renovate.json or renovate.json5
+(comments and all other settings are kept) and the branches added and removed are reported.
+With --security-only-days a package rule disabling non security updates is also managed for branches that only get
+security fixes or whose end of life is within this number of days, e.g.:
+
+	release-tool version-file --renovate-file renovate.json5 --security-only-days 30
+
+With --edition-source (repeated) the versions of several editions, possibly in different repos and with different lifetimes,
+are merged in a single file sorted by release. Each source is a list of key=value (edition and repo are required,
+min-version, lifetime-months and lts-lifetime-months default to the flags), with based-on the versions of an edition
+are linked to the version with the same release of the edition they are built from, e.g.:
+
+	release-tool version-file \
+		--edition-source edition=kuma,repo=kumahq/kuma \
+		--edition-source edition=kong-mesh,repo=Kong/kong-mesh,lts-lifetime-months=36,based-on=kuma
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		gqlClient, err := github.NewGQLClient(config.useGHAuth)
-		if err != nil {
-			return err
+		switch OutFormat(versionFileFormat) {
+		case FormatYaml, FormatJson, FormatEndOfLife, FormatICS, FormatGHAMatrix:
+		default:
+			return usageErrorf("invalid --format %q (must be %s, %s, %s, %s or %s)", versionFileFormat, FormatYaml, FormatJson, FormatEndOfLife, FormatICS, FormatGHAMatrix)
 		}
-
-		res, err := gqlClient.ReleaseGraphQL(config.repo)
-		if err != nil {
-			return err
-		}
-		minVersionVer := semver.MustParse(minVersion)
-		byVersion := map[string][]github.GQLRelease{}
-		for i := range res {
-			curVersion := res[i].SemVer()
-			if curVersion.Prerelease() != "" {
-				continue // Ignore prereleases
+		if githubOutput != "" {
+			if OutFormat(versionFileFormat) != FormatGHAMatrix {
+				return usageErrorf("--github-output requires --format %s", FormatGHAMatrix)
 			}
-			if curVersion.LessThan(minVersionVer) {
-				continue
+			if os.Getenv(envGitHubOutput) == "" {
+				return usageErrorf("--github-output requires $%s to be set", envGitHubOutput)
 			}
-			release := fmt.Sprintf("%d.%d.x", curVersion.Major(), curVersion.Minor())
-			byVersion[release] = append(byVersion[release], res[i])
 		}
-		var out []versionfile.VersionEntry
-		for releaseName, releases := range byVersion {
-			res, err := versionfile.BuildVersionEntry(edition, releaseName, lifetimeMonths, ltsLifetimeMonths, releases)
+		if (activeBranches || renovateFile != "") && OutFormat(versionFileFormat) != FormatYaml {
+			return usageErrorf("--active-branches and --renovate-file can't be used with --format")
+		}
+		if securityOnlyDays < 0 {
+			return usageErrorf("--security-only-days can't be negative")
+		}
+		if securityOnlyDays > 0 && renovateFile == "" {
+			return usageErrorf("--security-only-days requires --renovate-file")
+		}
+
+		var sources []editionSource
+		for _, value := range editionSources {
+			src, err := parseEditionSource(value)
 			if err != nil {
 				return err
 			}
-			out = append(out, res)
-		}
-		sort.Slice(out, func(i, j int) bool {
-			return out[i].Less(out[j])
-		})
-		// Add the dev version
-		devVersion := versionfile.VersionEntry{
-			Edition: edition,
-			Version: "preview",
-			Branch:  "master",
-			Label:   "dev",
-			Release: regexp.MustCompile(`\.[0-9]+$`).ReplaceAllString(semver.MustParse(out[len(out)-1].Version).IncMinor().String(), ".x"),
-		}
-		out = append(out, devVersion)
+			sources = append(sources, src)
+		}
+		if len(sources) > 0 {
+			switch {
+			case activeBranches || renovateFile != "":
+				return usageErrorf("--edition-source can't be used with --active-branches or --renovate-file")
+			case OutFormat(versionFileFormat) == FormatEndOfLife || OutFormat(versionFileFormat) == FormatGHAMatrix:
+				return usageErrorf("--edition-source can't be used with --format %s", versionFileFormat)
+			}
+		}
+		at, err := versionFileAsOf()
+		if err != nil {
+			return err
+		}
+		forge, err := newForge()
+		if err != nil {
+			return err
+		}
+		var out []versionfile.VersionEntry
+		if len(sources) > 0 {
+			out, err = buildMergedVersionFile(forge, sources)
+		} else {
+			out, err = buildVersionFile(forge, defaultEditionSource())
+		}
+		if err != nil {
+			return err
+		}
+		if renovateFile != "" {
+			return updateRenovateFile(out, at)
+		}
 		if activeBranches {
-			var branches []string
-			for _, v := range out {
-				t, _ := time.Parse(time.DateOnly, v.EndOfLifeDate)
-				if v.EndOfLifeDate == "" || time.Now().Before(t) {
-					branches = append(branches, v.Branch)
-				}
+			branches := nonEOLBranches(out, at)
+			result.Found = branches
+			result.Data = ActiveBranches{branches}
+			if result.isJson() {
+				return nil
 			}
 			return json.NewEncoder(cmd.OutOrStdout()).Encode(ActiveBranches{branches})
 		}
-		return yaml.NewEncoder(cmd.OutOrStdout()).Encode(out)
+		for _, v := range out {
+			result.Found = append(result.Found, v.Release)
+		}
+		var data any = out
+		switch OutFormat(versionFileFormat) {
+		case FormatEndOfLife:
+			data = versionfile.EndOfLifeCycles(out)
+		case FormatGHAMatrix:
+			matrix := versionfile.ActiveMatrix(out, at)
+			if err := writeGitHubOutput(githubOutput, matrix); err != nil {
+				return err
+			}
+			data = matrix
+		}
+		result.Data = data
+		if result.isJson() {
+			return nil
+		}
+		switch OutFormat(versionFileFormat) {
+		case FormatGHAMatrix:
+			// A single line so it can be used as is in a GitHub Actions output
+			return json.NewEncoder(cmd.OutOrStdout()).Encode(data)
+		case FormatYaml:
+			return yaml.NewEncoder(cmd.OutOrStdout()).Encode(data)
+		case FormatICS:
+			return versionfile.WriteICS(cmd.OutOrStdout(), out)
+		}
+		e := json.NewEncoder(cmd.OutOrStdout())
+		e.SetIndent("", "  ")
+		return e.Encode(data)
 	},
 }
 
+// versionFileAsOf returns the date of --as-of, today if it's not set.
+func versionFileAsOf() (time.Time, error) {
+	if asOf == "" {
+		return time.Now(), nil
+	}
+	t, err := time.Parse(time.DateOnly, asOf)
+	if err != nil {
+		return t, usageErrorf("invalid --as-of %q (must be YYYY-MM-DD)", asOf)
+	}
+	return t, nil
+}
+
+// editionSource is where the versions of an edition come from and the lifetime of its versions.
+type editionSource struct {
+	edition           string
+	repo              string
+	minVersion        string
+	lifetimeMonths    int
+	ltsLifetimeMonths int
+	// basedOn is the edition this one is built from
+	basedOn string
+}
+
+// defaultEditionSource is the edition set by --edition, --repo, --min-version and the lifetime flags.
+func defaultEditionSource() editionSource {
+	return editionSource{
+		edition:           config.edition,
+		repo:              config.repo,
+		minVersion:        config.minVersion,
+		lifetimeMonths:    config.lifetimeMonths,
+		ltsLifetimeMonths: config.ltsLifetimeMonths,
+	}
+}
+
+// parseEditionSource parses a --edition-source, fields not set default to defaultEditionSource.
+func parseEditionSource(value string) (editionSource, error) {
+	out := defaultEditionSource()
+	out.edition, out.repo = "", ""
+	for _, field := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return out, usageErrorf("invalid --edition-source %q: %q must be key=value", value, field)
+		}
+		var err error
+		switch k {
+		case "edition":
+			out.edition = v
+		case "repo":
+			out.repo = v
+		case "min-version":
+			out.minVersion = v
+		case "lifetime-months":
+			out.lifetimeMonths, err = strconv.Atoi(v)
+		case "lts-lifetime-months":
+			out.ltsLifetimeMonths, err = strconv.Atoi(v)
+		case "based-on":
+			out.basedOn = v
+		default:
+			return out, usageErrorf("invalid --edition-source %q: unknown key %q", value, k)
+		}
+		if err != nil {
+			return out, usageErrorf("invalid --edition-source %q: %s is not a number", value, k)
+		}
+	}
+	if out.edition == "" || out.repo == "" {
+		return out, usageErrorf("invalid --edition-source %q: edition and repo must be set", value)
+	}
+	return out, nil
+}
+
+// buildVersionFile returns the entries of the versions file of src with the version in development last.
+func buildVersionFile(forge github.Forge, src editionSource) ([]versionfile.VersionEntry, error) {
+	minVersionVer, err := semver.NewVersion(src.minVersion)
+	if err != nil {
+		return nil, usageErrorf("invalid min version %q of %s: %s", src.minVersion, src.edition, err)
+	}
+	allReleases, err := forge.Releases(src.repo)
+	if err != nil {
+		return nil, err
+	}
+	res, err := parseReleases(allReleases)
+	if err != nil {
+		return nil, err
+	}
+	var releases []github.ParsedRelease
+	for _, r := range res {
+		if r.Version.Prerelease() != "" {
+			continue // Ignore prereleases
+		}
+		if r.Version.LessThan(minVersionVer) {
+			continue
+		}
+		releases = append(releases, r)
+	}
+	out, err := versionfile.BuildVersionEntries(src.edition, versionfile.SupportPolicy{
+		LifetimeMonths:      src.lifetimeMonths,
+		LTSLifetimeMonths:   src.ltsLifetimeMonths,
+		EOLAfterMinors:      config.eolAfterMinors,
+		ActiveSupportMonths: config.activeSupportMonths,
+		ActiveSupportMinors: config.activeSupportMinors,
+	}, releases)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		// There's no version to derive the dev version from
+		slog.Warn("no release found", "repo", src.repo, "minVersion", src.minVersion)
+		return out, nil
+	}
+	// Add the dev version
+	devVersion := versionfile.VersionEntry{
+		Edition: src.edition,
+		Version: "preview",
+		Branch:  "master",
+		Label:   "dev",
+		Release: regexp.MustCompile(`\.[0-9]+$`).ReplaceAllString(semver.MustParse(out[len(out)-1].Version).IncMinor().String(), ".x"),
+	}
+	return append(out, devVersion), nil
+}
+
+// buildMergedVersionFile builds the versions file of each source and merges them.
+func buildMergedVersionFile(forge github.Forge, sources []editionSource) ([]versionfile.VersionEntry, error) {
+	var editions []versionfile.Edition
+	for _, src := range sources {
+		entries, err := buildVersionFile(forge, src)
+		if err != nil {
+			return nil, err
+		}
+		editions = append(editions, versionfile.Edition{Name: src.edition, Entries: entries, BasedOn: src.basedOn})
+	}
+	out, err := versionfile.MergeEditions(editions)
+	if err != nil {
+		return nil, usageError(err)
+	}
+	return out, nil
+}
+
+// writeGitHubOutput appends `name=<value as json>` to the file at $GITHUB_OUTPUT, it does nothing if name is empty.
+func writeGitHubOutput(name string, value any) error {
+	if name == "" {
+		return nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(os.Getenv(envGitHubOutput), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "%s=%s\n", name, b); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func nonEOLBranches(entries []versionfile.VersionEntry, at time.Time) []string {
+	var out []string
+	for _, v := range entries {
+		if v.Phase(at) != versionfile.PhaseEOL {
+			out = append(out, v.Branch)
+		}
+	}
+	return out
+}
+
+// updateRenovateFile merges the branches not EOL at `at` in the renovate config at renovateFile.
+func updateRenovateFile(entries []versionfile.VersionEntry, at time.Time) error {
+	content, err := os.ReadFile(renovateFile)
+	if err != nil {
+		return err
+	}
+	var securityOnly []renovate.SecurityOnlyBranch
+	if securityOnlyDays > 0 {
+		soon := at.AddDate(0, 0, securityOnlyDays)
+		for _, v := range entries {
+			switch {
+			case v.Phase(at) == versionfile.PhaseEOL:
+			case v.Phase(soon) == versionfile.PhaseEOL:
+				securityOnly = append(securityOnly, renovate.SecurityOnlyBranch{Branch: v.Branch, Reason: "end of life " + v.EndOfLifeDate})
+			case v.Phase(at) == versionfile.PhaseSecurity:
+				securityOnly = append(securityOnly, renovate.SecurityOnlyBranch{Branch: v.Branch, Reason: "security fixes only"})
+			}
+		}
+	}
+	branches := nonEOLBranches(entries, at)
+	updated, changes, err := renovate.Update(content, branches, securityOnly)
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", renovateFile, err)
+	}
+	result.Found = branches
+	result.Data = changes
+	for _, b := range changes.Added {
+		result.printf("Added branch %s\n", b)
+	}
+	for _, b := range changes.Removed {
+		result.printf("Removed branch %s\n", b)
+	}
+	for _, b := range changes.SecurityOnly {
+		result.printf("Only security updates on branch %s\n", b)
+	}
+	if string(updated) == string(content) {
+		result.printf("%s is already up to date\n", renovateFile)
+		return nil
+	}
+	if dryRun {
+		return nil
+	}
+	return os.WriteFile(renovateFile, updated, 0o644)
+}
+
 func init() {
-	versionFile.Flags().StringVar(&config.repo, "repo", "kumahq/kuma", "The repository to query")
-	versionFile.Flags().StringVar(&edition, "edition", "kuma", "The edition of the product")
-	versionFile.Flags().IntVar(&lifetimeMonths, "lifetime-months", 12, "the number of months a version is valid for")
-	versionFile.Flags().IntVar(&ltsLifetimeMonths, "lts-lifetime-months", 24, "the number of months an lts version is valid for")
-	versionFile.Flags().StringVar(&minVersion, "min-version", "1.2.0", "The minimum version to build a version files on")
+	versionFile.PersistentFlags().StringVar(&config.edition, "edition", "kuma", "The edition of the product")
+	versionFile.PersistentFlags().IntVar(&config.lifetimeMonths, "lifetime-months", 12, "the number of months a version is valid for")
+	versionFile.PersistentFlags().IntVar(&config.ltsLifetimeMonths, "lts-lifetime-months", 24, "the number of months an lts version is valid for")
+	versionFile.PersistentFlags().IntVar(&config.eolAfterMinors, "eol-after-minors", 0, "If set a version is end of life when this number of newer minors are released (--lifetime-months is used until then, LTS and extended versions keep at least their lifetime)")
+	versionFile.PersistentFlags().IntVar(&config.activeSupportMonths, "active-support-months", 0, "If set the number of months a version gets bug fixes, it only gets security fixes after that")
+	versionFile.PersistentFlags().IntVar(&config.activeSupportMinors, "active-support-minors", 0, "If set a version only gets security fixes once this number of newer minors are released")
+	versionFile.PersistentFlags().StringVar(&config.minVersion, "min-version", "1.2.0", "The minimum version to build a version files on")
+	addReleaseParsingFlags(versionFile.PersistentFlags())
+	versionFile.PersistentFlags().StringVar(&asOf, "as-of", "", "The date (YYYY-MM-DD) the support phase of versions is computed at instead of today")
+	versionFile.Flags().StringVar(&versionFileFormat, "format", string(FormatYaml), fmt.Sprintf("The output format (%s, %s, %s for the release cycles of https://endoflife.date, %s for an iCalendar of release and end of life dates, %s for a GitHub Actions matrix of the versions not EOL)", FormatYaml, FormatJson, FormatEndOfLife, FormatICS, FormatGHAMatrix))
+	versionFile.Flags().StringVar(&githubOutput, "github-output", "", fmt.Sprintf("With --format %s also write the matrix to the output with this name in $%s", FormatGHAMatrix, envGitHubOutput))
 	versionFile.Flags().BoolVar(&activeBranches, "active-branches", false, "only output a json with the branches not EOL")
+	versionFile.Flags().StringVar(&renovateFile, "renovate-file", "", "Merge the branches not EOL in the baseBranchPatterns of this renovate.json or renovate.json5 in place")
+	versionFile.Flags().IntVar(&securityOnlyDays, "security-only-days", 0, "With --renovate-file only allow security updates on branches in security support or whose end of life is within this number of days")
+	versionFile.Flags().StringArrayVar(&editionSources, "edition-source", nil, "Merge the versions of this edition (e.g. edition=kong-mesh,repo=Kong/kong-mesh,lifetime-months=18,based-on=kuma), can be repeated and replaces --edition and --repo")
+	versionFile.Flags().BoolVar(&dryRun, "dry-run", false, "With --renovate-file print the changes without updating the file")
 }
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kumahq/ci-tools/cmd/internal/githubfake"
+)
+
+func TestVersionFileGitHubOutput(t *testing.T) {
+	fake := githubfake.Load(t, filepath.Join("testdata", "github.yaml"))
+	path := filepath.Join(t.TempDir(), "github_output")
+	if err := os.WriteFile(path, []byte("previous=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envGitHubOutput, path)
+
+	out, err := runCommand(t, fake, "version-file", "--format", "gha-matrix", "--github-output", "matrix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, `{"include":[`) {
+		t.Errorf("expected a single line matrix, got %q", out)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "previous=1\nmatrix=" + out; string(b) != expected {
+		t.Errorf("got %q expected %q", b, expected)
+	}
+}
+
+func TestVersionFileWithoutRelease(t *testing.T) {
+	fake := githubfake.Load(t, filepath.Join("testdata", "github.yaml"))
+	out, err := runCommand(t, fake, "version-file", "--min-version", "99.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "[]\n" {
+		t.Errorf("expected an empty file got %q", out)
+	}
+}
+
+func TestVersionFileGitHubOutputRequiresMatrix(t *testing.T) {
+	fake := githubfake.Load(t, filepath.Join("testdata", "github.yaml"))
+	t.Setenv(envGitHubOutput, filepath.Join(t.TempDir(), "github_output"))
+	_, err := runCommand(t, fake, "version-file", "--github-output", "matrix")
+	if exitCode(err) != ExitUsage {
+		t.Errorf("expected a usage error got %v", err)
+	}
+}
+
+func TestVersionFileRenovateFile(t *testing.T) {
+	fake := githubfake.Load(t, filepath.Join("testdata", "github.yaml"))
+	path := filepath.Join(t.TempDir(), "renovate.json5")
+	content := `{
+  // keep me
+  extends: ["config:recommended"],
+  baseBranchPatterns: ["master", "release-2.10"],
+}
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCommand(t, fake, "version-file", "--renovate-file", path, "--as-of", "2026-10-18", "--security-only-days", "365")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedOut := "Added branch release-2.11\nAdded branch release-2.12\nRemoved branch release-2.10\nOnly security updates on branch release-2.11\n"
+	if out != expectedOut {
+		t.Errorf("got %q expected %q", out, expectedOut)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  // keep me
+  extends: ["config:recommended"],
+  baseBranchPatterns: ["release-2.11", "release-2.12", "master"],
+  "packageRules": [
+    {
+      "description": "Managed by release-tool: only security updates on release-2.11 (end of life 2027-06-20)",
+      "matchBaseBranches": [
+        "release-2.11"
+      ],
+      "matchPackageNames": [
+        "*"
+      ],
+      "enabled": false
+    }
+  ],
+}
+`
+	if string(b) != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", b, expected)
+	}
+
+	out, err = runCommand(t, fake, "version-file", "--renovate-file", path, "--as-of", "2026-10-18", "--security-only-days", "365")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expectedOut := "Only security updates on branch release-2.11\n" + path + " is already up to date\n"; out != expectedOut {
+		t.Errorf("got %q expected %q", out, expectedOut)
+	}
+}
+
+func TestEOLReportThresholds(t *testing.T) {
+	tests := []struct {
+		desc string
+		args []string
+		fail bool
+	}{
+		{desc: "nothing to report", args: []string{"--as-of", "2026-10-18", "--fail-within-days", "30", "--fail-on-eol-commits"}},
+		{desc: "reaching end of life", args: []string{"--as-of", "2027-06-01", "--fail-within-days", "30"}, fail: true},
+		{desc: "reaching end of life after the threshold", args: []string{"--as-of", "2027-06-01", "--fail-within-days", "7"}},
+		{desc: "commits on an end of life branch", args: []string{"--as-of", "2027-07-01", "--fail-on-eol-commits"}, fail: true},
+		{desc: "commits on an end of life branch without threshold", args: []string{"--as-of", "2027-07-01"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			fake := githubfake.Load(t, filepath.Join("testdata", "github.yaml"))
+			_, err := runCommand(t, fake, append([]string{"version-file", "eol-report"}, tt.args...)...)
+			if tt.fail && exitCode(err) != ExitFailure {
+				t.Errorf("expected a failure got %v", err)
+			}
+			if !tt.fail && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestEOLReportTagNotOnBranch(t *testing.T) {
+	fixture, err := githubfake.LoadFixture(filepath.Join("testdata", "github.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The branch was recreated from an older commit so the tag isn't in its history anymore
+	repo := fixture.Repos["kumahq/kuma"]
+	repo.MergeBases = append(repo.MergeBases, githubfake.MergeBase{Base: "b001", Head: "release-2.11", Commit: "a000"})
+	_, err = runCommand(t, githubfake.New(t, fixture), "version-file", "eol-report", "--as-of", "2027-07-01")
+	if err == nil || !strings.Contains(err.Error(), "tag 2.11.0 isn't on branch release-2.11") {
+		t.Errorf("expected the tag not to be on the branch got %v", err)
+	}
+}
+
+func TestVersionFileEditionSourceErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		args []string
+	}{
+		{desc: "missing repo", args: []string{"--edition-source", "edition=kuma"}},
+		{desc: "unknown key", args: []string{"--edition-source", "edition=kuma,repo=kumahq/kuma,lifetime=12"}},
+		{desc: "invalid lifetime", args: []string{"--edition-source", "edition=kuma,repo=kumahq/kuma,lifetime-months=a"}},
+		{desc: "based on an edition not merged", args: []string{"--edition-source", "edition=kong-mesh,repo=Kong/kong-mesh,based-on=kuma"}},
+		{desc: "duplicate edition", args: []string{"--edition-source", "edition=kuma,repo=kumahq/kuma", "--edition-source", "edition=kuma,repo=Kong/kong-mesh"}},
+		{desc: "active branches", args: []string{"--edition-source", "edition=kuma,repo=kumahq/kuma", "--active-branches"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			fake := githubfake.Load(t, filepath.Join("testdata", "github.yaml"))
+			_, err := runCommand(t, fake, append([]string{"version-file"}, tt.args...)...)
+			if exitCode(err) != ExitUsage {
+				t.Errorf("expected a usage error got %v", err)
+			}
+		})
+	}
+}